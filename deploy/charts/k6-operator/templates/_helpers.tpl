@@ -60,3 +60,10 @@ Create the name of the service account to use
 {{- default "default" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Jobs in dedicated namespaces require cluster wide permissions
+*/}}
+{{- define "k6-operator.roleKind" -}}
+{{- if eq .Values.config.namespaces.mode "shared" }}Role{{ else }}ClusterRole{{ end }}
+{{- end }}
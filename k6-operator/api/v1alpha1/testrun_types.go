@@ -0,0 +1,182 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// TestRunSpec defines the k6 test to run. It mirrors the test runs of the webapp, for a single location.
+type TestRunSpec struct {
+	// Target is passed to the test script as the TARGET environment variable.
+	// +optional
+	Target string `json:"target,omitempty"`
+	// Env are environment variables passed to the test script.
+	// +optional
+	Env map[string]string `json:"env,omitempty"`
+	// Labels are added as tags to all the metrics.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// SourceRepo is the git repository of the test script, without the scheme (ex. github.com/org/repo).
+	SourceRepo string `json:"sourceRepo"`
+	// SourceRef is the git branch, tag or commit to check out.
+	// +kubebuilder:default=main
+	// +optional
+	SourceRef string `json:"sourceRef,omitempty"`
+	// SourceScript is the path of the test script in the repository.
+	SourceScript string `json:"sourceScript"`
+
+	// Workers is the number of worker pods running the test.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	Workers int32 `json:"workers,omitempty"`
+	// Resources are the resources requested by each worker pod. Only cpu and memory are used.
+	// +optional
+	Resources corev1.ResourceList `json:"resources,omitempty"`
+	// NodeSelector is added to the worker pods.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// DedicatedNodes runs every worker pod on its own node.
+	// +optional
+	DedicatedNodes bool `json:"dedicatedNodes,omitempty"`
+	// Deadline is the maximum duration of the test, once the worker pods are created.
+	// +optional
+	Deadline *metav1.Duration `json:"deadline,omitempty"`
+
+	// StartAt is when the test starts. When not set, it starts shortly after the worker pods are ready.
+	// +optional
+	StartAt *metav1.Time `json:"startAt,omitempty"`
+	// LateStartPolicy decides what happens when the worker pods are ready after StartAt.
+	// +kubebuilder:validation:Enum=start;fail
+	// +optional
+	LateStartPolicy string `json:"lateStartPolicy,omitempty"`
+	// LateStartTolerance is the lateness accepted before the LateStartPolicy applies.
+	// +optional
+	LateStartTolerance *metav1.Duration `json:"lateStartTolerance,omitempty"`
+	// IgnitionStagger spreads the start of the worker pods over the given duration, instead of starting all of them
+	// at once.
+	// +optional
+	IgnitionStagger *metav1.Duration `json:"ignitionStagger,omitempty"`
+	// FailureTolerance is the number (ex. 2) or percentage (ex. 10%) of the worker pods which can fail while the test
+	// still completes, as degraded.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	FailureTolerance *intstr.IntOrString `json:"failureTolerance,omitempty"`
+
+	// Cancel stops the test. The worker pods are removed, but the test run is kept.
+	// +optional
+	Cancel bool `json:"cancel,omitempty"`
+
+	// Output is where the metrics are sent.
+	// +optional
+	Output TestRunOutput `json:"output,omitempty"`
+}
+
+// TestRunOutput configures where the metrics of a test run are sent.
+type TestRunOutput struct {
+	// InfluxDB is an InfluxDB v2 server.
+	// +optional
+	InfluxDB *InfluxDBOutput `json:"influxdb,omitempty"`
+}
+
+// InfluxDBOutput sends the metrics to an InfluxDB v2 server.
+type InfluxDBOutput struct {
+	URL          string `json:"url"`
+	Organization string `json:"organization"`
+	Bucket       string `json:"bucket"`
+	// TokenSecretRef is the key of a secret, in the namespace of the test run, holding the InfluxDB token.
+	// +optional
+	TokenSecretRef *corev1.SecretKeySelector `json:"tokenSecretRef,omitempty"`
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// PodIgnition records when a worker pod was un-paused.
+type PodIgnition struct {
+	Pod       string      `json:"pod"`
+	IgnitedAt metav1.Time `json:"ignitedAt"`
+}
+
+// Ignition reports how the worker pods were started.
+type Ignition struct {
+	Pods []PodIgnition `json:"pods,omitempty"`
+	// Spread is the time between the first and the last pod being un-paused.
+	Spread metav1.Duration `json:"spread"`
+	// Lateness is how late, compared to the start time, the ignition began.
+	Lateness metav1.Duration `json:"lateness"`
+}
+
+// TestRunStatus defines the observed state of TestRun
+type TestRunStatus struct {
+	// Phase is the status of the test run: pending, queued, ready, running, canceled, completed or failed.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Description explains the phase.
+	// +optional
+	Description string `json:"description,omitempty"`
+	// StartTime is when the test starts, or started, on all the worker pods.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Ignition reports how the worker pods were started.
+	// +optional
+	Ignition *Ignition `json:"ignition,omitempty"`
+	// LostSegments are the IDs of the segments no worker pod completed.
+	// +optional
+	LostSegments []string `json:"lostSegments,omitempty"`
+	// DisruptedSegments are the IDs of the lost segments whose worker pod was evicted by a node disruption.
+	// +optional
+	DisruptedSegments []string `json:"disruptedSegments,omitempty"`
+	// Conditions are the states of the phases of the test run.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was decided on.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Workers",type=integer,JSONPath=`.spec.workers`
+//+kubebuilder:printcolumn:name="Start",type=date,JSONPath=`.status.startTime`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// TestRun is a k6 load test run by the operator, without the webapp.
+type TestRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TestRunSpec   `json:"spec,omitempty"`
+	Status TestRunStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TestRunList contains a list of TestRun
+type TestRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TestRun `json:"items"`
+}
+
+// CPU returns the cpu requested by each worker pod, or zero.
+func (s *TestRunSpec) CPU() resource.Quantity {
+	return s.Resources[corev1.ResourceCPU]
+}
+
+// Memory returns the memory requested by each worker pod, or zero.
+func (s *TestRunSpec) Memory() resource.Quantity {
+	return s.Resources[corev1.ResourceMemory]
+}
+
+func init() {
+	SchemeBuilder.Register(&TestRun{}, &TestRunList{})
+}
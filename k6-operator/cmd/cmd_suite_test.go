@@ -0,0 +1,17 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCmd(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Cmd Suite")
+}
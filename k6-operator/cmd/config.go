@@ -0,0 +1,260 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+// apiFlags holds the API settings given on the command line. They take precedence over the secret files.
+type apiFlags struct {
+	region             string
+	locationsFile      string
+	endpoint           string
+	user               string
+	password           string
+	token              string
+	clientCert         string
+	clientKey          string
+	caCert             string
+	oauth2TokenURL     string
+	oauth2ClientID     string
+	oauth2ClientSecret string
+	oauth2Scopes       string
+}
+
+// returns the path of the file /run/secrets/<name>, if it exists
+func secretFilePath(name string) string {
+	file := path.Join("/run/secrets", name)
+	if _, err := os.Stat(file); err != nil {
+		return ""
+	}
+
+	return file
+}
+
+// firstNonEmpty returns the first non empty value.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// apiSettings holds the API settings read from flags and secret files. They're read again every time the secret
+// files change, so they're kept out of the global options, which are read concurrently by the reconcilers.
+type apiSettings struct {
+	endpoint           string
+	user               string
+	password           string
+	token              string
+	clientCert         string
+	clientKey          string
+	caCert             string
+	oauth2TokenURL     string
+	oauth2ClientID     string
+	oauth2ClientSecret string
+	oauth2Scopes       string
+}
+
+// loadAPISettings reads the API settings from flags and secret files. It's called at startup and every time the
+// secret files change.
+func loadAPISettings(f apiFlags) apiSettings {
+	return apiSettings{
+		endpoint:           firstNonEmpty(f.endpoint, fromSecretFile("API_ENDPOINT"), options.APIEndpoint),
+		user:               firstNonEmpty(f.user, fromSecretFile("API_USER"), options.APIUser),
+		password:           firstNonEmpty(f.password, fromSecretFile("API_PASSWORD"), options.APIPassword),
+		token:              firstNonEmpty(f.token, fromSecretFile("API_TOKEN")),
+		clientCert:         firstNonEmpty(f.clientCert, secretFilePath("API_CLIENT_CERT")),
+		clientKey:          firstNonEmpty(f.clientKey, secretFilePath("API_CLIENT_KEY")),
+		caCert:             firstNonEmpty(f.caCert, secretFilePath("API_CA_CERT")),
+		oauth2TokenURL:     firstNonEmpty(f.oauth2TokenURL, fromSecretFile("API_OAUTH2_TOKEN_URL")),
+		oauth2ClientID:     firstNonEmpty(f.oauth2ClientID, fromSecretFile("API_OAUTH2_CLIENT_ID")),
+		oauth2ClientSecret: firstNonEmpty(f.oauth2ClientSecret, fromSecretFile("API_OAUTH2_CLIENT_SECRET")),
+		oauth2Scopes:       firstNonEmpty(f.oauth2Scopes, fromSecretFile("API_OAUTH2_SCOPES")),
+	}
+}
+
+// loadLocations reads the locations served by this controller, from the locations file or the region.
+func loadLocations(f apiFlags, settings apiSettings) ([]options.Location, error) {
+	var locationsData string
+	if f.locationsFile != "" {
+		data, err := os.ReadFile(f.locationsFile)
+		if err != nil {
+			return nil, err
+		}
+		locationsData = string(data)
+	} else {
+		locationsData = fromSecretFile("LOCATIONS")
+	}
+
+	locations := []options.Location{}
+	if locationsData != "" {
+		if err := yaml.Unmarshal([]byte(locationsData), &locations); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(locations) == 0 {
+		region := firstNonEmpty(f.region, fromSecretFile("REGION"))
+		if region == "" && options.Source == options.SourceCRD {
+			// without the webapp, the location is only used to tag the metrics
+			region = "local"
+		}
+		if region == "" {
+			return nil, fmt.Errorf("loadtesting-region is required")
+		}
+		locations = append(locations, options.Location{Name: region})
+	}
+
+	if err := setupLocations(locations, settings); err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+// setupLocations fills in the defaults of every location and validates them.
+func setupLocations(locations []options.Location, settings apiSettings) error {
+	namespaces := map[string]string{}
+	for idx := range locations {
+		location := &locations[idx]
+		if location.Name == "" {
+			return fmt.Errorf("location #%d has no name", idx)
+		}
+		if location.APIEndpoint == "" {
+			location.APIEndpoint = settings.endpoint
+		}
+		if location.APIUser == "" {
+			location.APIUser = settings.user
+		}
+		if location.APIPassword == "" {
+			location.APIPassword = settings.password
+		}
+		if location.Namespace == "" {
+			location.Namespace = options.JobNamespace
+		}
+		if location.Source == "" {
+			location.Source = options.Source
+		}
+		if location.Source != options.SourceAPI && location.Source != options.SourceCRD {
+			return fmt.Errorf("location %s has an invalid source %s", location.Name, location.Source)
+		}
+
+		if len(locations) > 1 && options.NamespaceMode == options.NamespaceModeShared {
+			// Jobs are named after test runs, so locations can't share a namespace
+			if other, found := namespaces[location.Namespace]; found {
+				return fmt.Errorf("locations %s and %s use the same namespace %s", other, location.Name, location.Namespace)
+			}
+			namespaces[location.Namespace] = location.Name
+		}
+	}
+
+	return nil
+}
+
+// locationNamespaces maps the locations to the namespace where their jobs are created.
+func locationNamespaces(locations []options.Location) map[string]string {
+	namespaces := make(map[string]string, len(locations))
+	for _, location := range locations {
+		namespaces[location.Name] = location.Namespace
+	}
+	return namespaces
+}
+
+// apiAuthenticators returns the configured authentication methods for the API of a location. An empty list means
+// basic auth is used.
+func apiAuthenticators(location options.Location, settings apiSettings) []client.Authenticator {
+	authenticators := []client.Authenticator{}
+
+	if settings.clientCert != "" || settings.caCert != "" {
+		authenticators = append(authenticators, &client.ClientCertificate{
+			CertFile: settings.clientCert,
+			KeyFile:  settings.clientKey,
+			CAFile:   settings.caCert,
+		})
+	}
+
+	switch {
+	case location.APIToken != "":
+		authenticators = append(authenticators, &client.BearerToken{Token: location.APIToken})
+	case settings.token != "":
+		authenticators = append(authenticators, &client.BearerToken{Token: settings.token})
+	case settings.oauth2TokenURL != "":
+		authenticators = append(authenticators, &client.OAuth2ClientCredentials{
+			TokenURL:     settings.oauth2TokenURL,
+			ClientID:     settings.oauth2ClientID,
+			ClientSecret: settings.oauth2ClientSecret,
+			Scopes:       strings.Fields(settings.oauth2Scopes),
+		})
+	case len(authenticators) > 0 && settings.clientCert == "":
+		// only a custom CA bundle was configured, so we still need basic auth
+		authenticators = append(authenticators, &client.BasicAuth{
+			Username: location.APIUser,
+			Password: location.APIPassword,
+		})
+	}
+
+	return authenticators
+}
+
+// apiClientOptions returns the options of the API client of a location.
+func apiClientOptions(location options.Location, settings apiSettings) []client.Option {
+	opts := []client.Option{
+		client.WithBaseURL(location.APIEndpoint),
+		client.WithUsername(location.APIUser),
+		client.WithPassword(location.APIPassword),
+		client.WithRegion(location.Name),
+		client.WithLogger(ctrl.Log.WithName("loadtesting-client").WithValues("location", location.Name)),
+		client.WithRetries(options.APIRetries, 500*time.Millisecond),
+		client.WithRateLimit(float32(options.APIQPS), options.APIBurst),
+		client.WithCircuitBreaker(options.APIBreakerThreshold, options.APIBreakerCooldown),
+		client.WithWatchTransport(options.APIWatchTransport),
+	}
+	for _, authenticator := range apiAuthenticators(location, settings) {
+		opts = append(opts, client.WithAuthenticator(authenticator))
+	}
+
+	return opts
+}
+
+// reloadAPIClients re-reads the API settings and swaps them in the running clients. Locations can't be added or
+// removed, nor their namespace changed, without a restart.
+func reloadAPIClients(f apiFlags, apiClients map[string]*client.UncachedClient) error {
+	settings := loadAPISettings(f)
+	locations, err := loadLocations(f, settings)
+	if err != nil {
+		return err
+	}
+
+	for _, location := range locations {
+		if location.Source != options.SourceAPI {
+			continue
+		}
+
+		apiClient, found := apiClients[location.Name]
+		if !found {
+			setupLog.Info("ignoring new location, a restart is required to serve it", "location", location.Name)
+			continue
+		}
+
+		if err := apiClient.Reconfigure(apiClientOptions(location, settings)...); err != nil {
+			return fmt.Errorf("location %s: %w", location.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+var _ = Describe("setupLocations", func() {
+	BeforeEach(func() {
+		DeferCleanup(func(mode string) { options.NamespaceMode = mode }, options.NamespaceMode)
+	})
+
+	locations := func() []options.Location {
+		return []options.Location{{Name: "east"}, {Name: "west"}}
+	}
+
+	It("requires every location to have its own namespace in the shared namespace mode", func() {
+		options.NamespaceMode = options.NamespaceModeShared
+		Expect(setupLocations(locations(), apiSettings{})).To(MatchError(ContainSubstring("use the same namespace")))
+
+		distinct := []options.Location{{Name: "east", Namespace: "east"}, {Name: "west", Namespace: "west"}}
+		Expect(setupLocations(distinct, apiSettings{})).To(Succeed())
+	})
+
+	DescribeTable("lets locations share a namespace when test runs get their own",
+		func(mode string) {
+			options.NamespaceMode = mode
+			Expect(setupLocations(locations(), apiSettings{})).To(Succeed())
+		},
+		Entry("per test run", options.NamespaceModePerTestRun),
+		Entry("per team", options.NamespaceModePerTeam),
+	)
+})
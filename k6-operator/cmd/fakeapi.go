@@ -0,0 +1,39 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/fake"
+)
+
+// serveFakeAPI serves the in-memory API on addr, so test runs can be added and canceled with plain HTTP requests
+// while developing against a local cluster.
+func serveFakeAPI(mgr manager.Manager, addr string, api *fake.API) error {
+	return mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		server := &http.Server{
+			Addr:              addr,
+			Handler:           api.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+
+		setupLog.Info("serving fake API", "address", addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}))
+}
@@ -8,6 +8,7 @@ import (
 	"flag"
 	"os"
 	"path"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -23,9 +24,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/yaml"
 
+	orderlyapev1alpha1 "github.com/ReviewSignal/loadtesting/k6-operator/api/v1alpha1"
 	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting"
 	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/crd"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/fake"
 	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
 
 	"github.com/ReviewSignal/loadtesting/k6-operator/internal/controller"
@@ -40,6 +45,8 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
+	utilruntime.Must(orderlyapev1alpha1.AddToScheme(scheme))
+
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -62,17 +69,81 @@ func main() {
 	var secureMetrics bool
 	var enableHTTP2 bool
 
-	var loadtestingRegion string
-	var loadtestingAPIEndpoint string
-	var loadtestingAPIUser string
-	var loadtestingAPIPassword string
+	var api apiFlags
+	var source string
 	var jobNamespace string
+	var namespaceMode string
+	var namespacePrefix string
+	var namespaceTeamLabel string
+	var namespacePolicyFile string
+	var maxClockSkew time.Duration
+	var maxConcurrentReconciles int
+	var notificationsSecret string
+	var notFoundConfirmations int
+	var orphanRetention string
+	var orphanSweepInterval string
+	var fakeAPI bool
+	var fakeAPIAddr string
 
-	flag.StringVar(&loadtestingAPIEndpoint, "loadtesting-api-endpoint", "", "The API endpoint for controlling the k6 load testing.")
-	flag.StringVar(&loadtestingAPIUser, "loadtesting-api-user", "", "The API user for controlling the k6 load testing.")
-	flag.StringVar(&loadtestingAPIPassword, "loadtesting-api-password", "", "The API password for controlling the k6 load testing.")
-	flag.StringVar(&loadtestingRegion, "loadtesting-region", "", "The region this controller is running in. Required.")
+	flag.StringVar(&api.endpoint, "loadtesting-api-endpoint", "", "The API endpoint for controlling the k6 load testing.")
+	flag.StringVar(&api.user, "loadtesting-api-user", "", "The API user for controlling the k6 load testing.")
+	flag.StringVar(&api.password, "loadtesting-api-password", "", "The API password for controlling the k6 load testing.")
+	flag.StringVar(&api.token, "loadtesting-api-token", "", "A bearer token used instead of basic auth for the API.")
+	flag.StringVar(&api.clientCert, "loadtesting-api-client-cert", "", "A TLS client certificate file used to authenticate against the API.")
+	flag.StringVar(&api.clientKey, "loadtesting-api-client-key", "", "The key file of the TLS client certificate.")
+	flag.StringVar(&api.caCert, "loadtesting-api-ca-cert", "", "A PEM bundle used to verify the API server certificate.")
+	flag.StringVar(&api.oauth2TokenURL, "loadtesting-api-oauth2-token-url", "",
+		"The OAuth2 token endpoint, for authenticating against the API with the client credentials flow.")
+	flag.StringVar(&api.oauth2ClientID, "loadtesting-api-oauth2-client-id", "", "The OAuth2 client ID.")
+	flag.StringVar(&api.oauth2ClientSecret, "loadtesting-api-oauth2-client-secret", "", "The OAuth2 client secret.")
+	flag.StringVar(&api.oauth2Scopes, "loadtesting-api-oauth2-scopes", "", "Space separated OAuth2 scopes.")
+	flag.IntVar(&options.APIRetries, "loadtesting-api-retries", options.APIRetries,
+		"How many times failed idempotent API requests are retried, with jittered exponential backoff.")
+	flag.Float64Var(&options.APIQPS, "loadtesting-api-qps", options.APIQPS,
+		"The maximum number of API requests per second, per location. Set to 0 to disable the rate limit.")
+	flag.IntVar(&options.APIBurst, "loadtesting-api-burst", options.APIBurst, "The burst of the API rate limit.")
+	flag.IntVar(&options.APIBreakerThreshold, "loadtesting-api-breaker-threshold", options.APIBreakerThreshold,
+		"The number of consecutive API failures which open the circuit breaker. Set to 0 to disable it.")
+	flag.DurationVar(&options.APIBreakerCooldown, "loadtesting-api-breaker-cooldown", options.APIBreakerCooldown,
+		"How long the circuit breaker stays open before probing the API again.")
+	flag.StringVar(&options.APIWatchTransport, "loadtesting-api-watch", options.APIWatchTransport,
+		"How to watch the API for test run changes: auto, poll, long-poll or sse. "+
+			"Streaming transports fall back to polling when the API doesn't advertise them.")
+	flag.StringVar(&source, "source", "",
+		"Where test runs are read from: api, the webapp API, or crd, the TestRun resources in the jobs namespace. "+
+			"Defaults to api.")
+	flag.BoolVar(&fakeAPI, "fake-api", false,
+		"Use an in-memory API instead of the webapp, for local development. Test runs are added by posting them "+
+			"to /runs on the fake API address.")
+	flag.StringVar(&fakeAPIAddr, "fake-api-bind-address", ":8090", "The address the fake API binds to.")
+	flag.StringVar(&api.region, "loadtesting-region", "", "The region this controller is running in. Required.")
+	flag.StringVar(&api.locationsFile, "locations-file", "",
+		"A YAML file listing the locations served by this controller, with their credentials, namespace, "+
+			"node selector, tolerations and worker defaults. Replaces loadtesting-region.")
 	flag.StringVar(&jobNamespace, "job-namespace", "", "The namespace to create the k6 jobs in. Defaults to the namespace the controller is running in.")
+	flag.StringVar(&namespaceMode, "namespace-mode", "",
+		"How namespaces are assigned to k6 jobs: shared, per-test-run or per-team. Defaults to shared.")
+	flag.StringVar(&namespacePrefix, "namespace-prefix", "",
+		"The prefix of the namespaces created for per-test-run and per-team namespace modes.")
+	flag.StringVar(&namespaceTeamLabel, "namespace-team-label", "",
+		"The test run label used to select the namespace in per-team namespace mode.")
+	flag.StringVar(&namespacePolicyFile, "namespace-policy-file", "",
+		"A YAML file with the resourceQuota, limitRange and networkPolicy specs applied to created namespaces.")
+	flag.DurationVar(&maxClockSkew, "max-clock-skew", options.MaxClockSkew,
+		"The maximum allowed offset between the controller and the API clocks before refusing to start tests. "+
+			"Set to 0 to disable the check.")
+	flag.StringVar(&notificationsSecret, "notifications-secret", "",
+		"The shared secret used to verify the job change notifications sent by the webapp to /notify on the "+
+			"webhook server. Notifications are disabled when empty.")
+	flag.IntVar(&notFoundConfirmations, "not-found-confirmations", 3,
+		"How many times in a row the API needs to report a test run as missing before its Kubernetes Job is deleted.")
+	flag.StringVar(&orphanRetention, "orphan-retention", "",
+		"How long the resources of test runs removed from the API, or canceled, are kept before being deleted. "+
+			"Defaults to 15m.")
+	flag.StringVar(&orphanSweepInterval, "orphan-sweep-interval", "",
+		"How often orphaned resources are looked for. Set to 0 to disable the sweeper. Defaults to 10m.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The maximum number of test runs reconciled in parallel.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -110,15 +181,6 @@ func main() {
 		TLSOpts: tlsOpts,
 	})
 
-	if loadtestingRegion == "" {
-		loadtestingRegion = fromSecretFile("REGION")
-	}
-	if loadtestingRegion == "" {
-		setupLog.Error(nil, "loadtesting-region is required")
-		os.Exit(1)
-	}
-	options.Region = loadtestingRegion
-
 	if jobNamespace == "" {
 		jobNamespace = fromSecretFile("JOBS_NAMESPACE")
 	}
@@ -127,33 +189,107 @@ func main() {
 	}
 	options.JobNamespace = jobNamespace
 
-	if loadtestingAPIEndpoint == "" {
-		loadtestingAPIEndpoint = fromSecretFile("API_ENDPOINT")
+	if namespaceMode == "" {
+		namespaceMode = fromSecretFile("NAMESPACE_MODE")
+	}
+	if namespaceMode != "" {
+		options.NamespaceMode = namespaceMode
+	}
+	switch options.NamespaceMode {
+	case options.NamespaceModeShared, options.NamespaceModePerTestRun, options.NamespaceModePerTeam:
+	default:
+		setupLog.Error(nil, "invalid namespace-mode", "mode", options.NamespaceMode)
+		os.Exit(1)
+	}
+
+	if namespacePrefix == "" {
+		namespacePrefix = fromSecretFile("NAMESPACE_PREFIX")
+	}
+	if namespacePrefix != "" {
+		options.NamespacePrefix = namespacePrefix
+	}
+
+	if namespaceTeamLabel == "" {
+		namespaceTeamLabel = fromSecretFile("NAMESPACE_TEAM_LABEL")
+	}
+	if namespaceTeamLabel != "" {
+		options.NamespaceTeamLabel = namespaceTeamLabel
+	}
+
+	var namespacePolicy *controller.NamespacePolicy
+	var namespacePolicyData string
+	if namespacePolicyFile != "" {
+		data, err := os.ReadFile(namespacePolicyFile)
+		if err != nil {
+			setupLog.Error(err, "unable to read namespace policy", "file", namespacePolicyFile)
+			os.Exit(1)
+		}
+		namespacePolicyData = string(data)
+	} else {
+		namespacePolicyData = fromSecretFile("NAMESPACE_POLICY")
+	}
+	if namespacePolicyData != "" {
+		namespacePolicy = &controller.NamespacePolicy{}
+		if err := yaml.Unmarshal([]byte(namespacePolicyData), namespacePolicy); err != nil {
+			setupLog.Error(err, "unable to parse namespace policy")
+			os.Exit(1)
+		}
+	}
+
+	if orphanRetention == "" {
+		orphanRetention = fromSecretFile("ORPHAN_RETENTION")
+	}
+	if orphanRetention != "" {
+		retention, err := time.ParseDuration(orphanRetention)
+		if err != nil {
+			setupLog.Error(err, "invalid orphan-retention")
+			os.Exit(1)
+		}
+		options.OrphanRetention = retention
+	}
+
+	if orphanSweepInterval == "" {
+		orphanSweepInterval = fromSecretFile("ORPHAN_SWEEP_INTERVAL")
 	}
-	if loadtestingAPIEndpoint != "" {
-		options.APIEndpoint = loadtestingAPIEndpoint
+	if orphanSweepInterval != "" {
+		interval, err := time.ParseDuration(orphanSweepInterval)
+		if err != nil {
+			setupLog.Error(err, "invalid orphan-sweep-interval")
+			os.Exit(1)
+		}
+		options.OrphanSweepInterval = interval
 	}
 
-	if loadtestingAPIUser == "" {
-		loadtestingAPIUser = fromSecretFile("API_USER")
+	options.MaxClockSkew = maxClockSkew
+
+	if source == "" {
+		source = fromSecretFile("SOURCE")
+	}
+	if source != "" {
+		options.Source = source
 	}
-	if loadtestingAPIUser != "" {
-		options.APIUser = loadtestingAPIUser
+
+	apiSettings := loadAPISettings(api)
+	locations, err := loadLocations(api, apiSettings)
+	if err != nil {
+		setupLog.Error(err, "invalid locations")
+		os.Exit(1)
 	}
+	options.LocationNamespaces = locationNamespaces(locations)
 
-	if loadtestingAPIPassword == "" {
-		loadtestingAPIPassword = fromSecretFile("API_PASSWORD")
+	// When jobs get their own namespaces, we need to watch the whole cluster
+	cacheNamespaces := map[string]cache.Config{}
+	for _, location := range locations {
+		cacheNamespaces[location.Namespace] = cache.Config{}
 	}
-	if loadtestingAPIPassword != "" {
-		options.APIPassword = loadtestingAPIPassword
+	if options.NamespaceMode != options.NamespaceModeShared {
+		cacheNamespaces = nil
 	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Cache: cache.Options{
-			DefaultNamespaces: map[string]cache.Config{
-				jobNamespace: {},
-			},
+			DefaultNamespaces: cacheNamespaces,
 		},
 		Metrics: metricsserver.Options{
 			BindAddress:   metricsAddr,
@@ -181,38 +317,148 @@ func main() {
 		os.Exit(1)
 	}
 
-	apiClient, err := client.NewUncachedClient(
-		client.WithBaseURL(options.APIEndpoint),
-		client.WithUsername(options.APIUser),
-		client.WithPassword(options.APIPassword),
-		client.WithRegion(options.Region),
-		client.WithLogger(ctrl.Log.WithName("loadtesting-client")),
-	)
-	if err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Deployment")
-		os.Exit(1)
+	var fakeAPIServer *fake.API
+	if fakeAPI {
+		fakeAPIServer = fake.NewAPI()
+		fakeAPIServer.StartDelay = 10 * time.Second
+		if err = serveFakeAPI(mgr, fakeAPIAddr, fakeAPIServer); err != nil {
+			setupLog.Error(err, "unable to set up fake API")
+			os.Exit(1)
+		}
 	}
 
-	if err = (&controller.TestRunReconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		APIClient: apiClient,
-		Location:  options.Region,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "TestRun")
-		os.Exit(1)
+	apiClients := map[string]*client.UncachedClient{}
+	notifiers := map[string]loadtesting.Notifier{}
+	reconcilers := []*controller.TestRunReconciler{}
+	for _, location := range locations {
+		reconciler := &controller.TestRunReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Location:                location.Name,
+			MaxClockSkew:            options.MaxClockSkew,
+			MaxConcurrentReconciles: maxConcurrentReconciles,
+			NotFoundConfirmations:   notFoundConfirmations,
+			NamespacePolicy:         namespacePolicy,
+			NodeSelector:            location.NodeSelector,
+			Tolerations:             location.Tolerations,
+			WorkerResources:         location.Workers,
+			SizingProfile:           location.Sizing,
+			Exclusive:               len(locations) == 1,
+		}
+		reconcilers = append(reconcilers, reconciler)
+
+		if location.Source == options.SourceCRD {
+			reconciler.APIClient = crd.NewClient(mgr, location.Name, location.Namespace)
+			if err = reconciler.SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "TestRun", "location", location.Name)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		if fakeAPIServer != nil {
+			fakeClient := fakeAPIServer.Client(location.Name)
+			reconciler.APIClient = fakeClient
+			if err = reconciler.SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "TestRun", "location", location.Name)
+				os.Exit(1)
+			}
+			notifiers[location.Name] = reconciler
+
+			pinger, err := loadtesting.NewPinger(fakeClient)
+			if err != nil {
+				setupLog.Error(err, "unable to set up pinger", "location", location.Name)
+				os.Exit(1)
+			}
+			if err = mgr.Add(pinger); err != nil {
+				setupLog.Error(err, "unable to set up pinger", "location", location.Name)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		apiClient, err := client.NewUncachedClient(apiClientOptions(location, apiSettings)...)
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Deployment")
+			os.Exit(1)
+		}
+		apiClients[location.Name] = apiClient
+
+		statusQueue, err := loadtesting.NewStatusQueue(apiClient)
+		if err != nil {
+			setupLog.Error(err, "unable to set up status queue", "location", location.Name)
+			os.Exit(1)
+		}
+		if err = mgr.Add(statusQueue); err != nil {
+			setupLog.Error(err, "unable to set up status queue", "location", location.Name)
+			os.Exit(1)
+		}
+		if err = mgr.AddReadyzCheck("loadtesting-api-"+location.Name, apiClient.Ready); err != nil {
+			setupLog.Error(err, "unable to set up ready check", "location", location.Name)
+			os.Exit(1)
+		}
+
+		reconciler.APIClient = apiClient
+		reconciler.StatusQueue = statusQueue
+		if err = reconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "TestRun", "location", location.Name)
+			os.Exit(1)
+		}
+		notifiers[location.Name] = reconciler
+
+		pinger, err := loadtesting.NewPinger(apiClient)
+		if err != nil {
+			setupLog.Error(err, "unable to set up pinger", "location", location.Name)
+			os.Exit(1)
+		}
+		if err = mgr.Add(pinger); err != nil {
+			setupLog.Error(err, "unable to set up pinger", "location", location.Name)
+			os.Exit(1)
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
-	pinger, err := loadtesting.NewPinger(apiClient)
+	if options.OrphanSweepInterval > 0 {
+		for _, reconciler := range reconcilers {
+			sweeper := &controller.OrphanSweeper{
+				Reconciler: reconciler,
+				Reader:     mgr.GetAPIReader(),
+				Retention:  options.OrphanRetention,
+				Interval:   options.OrphanSweepInterval,
+			}
+			if err = mgr.Add(sweeper); err != nil {
+				setupLog.Error(err, "unable to set up orphan sweeper", "location", reconciler.Location)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if notificationsSecret == "" {
+		notificationsSecret = fromSecretFile("NOTIFICATIONS_SECRET")
+	}
+	if notificationsSecret != "" {
+		notificationHandler, err := loadtesting.NewNotificationHandler([]byte(notificationsSecret), notifiers)
+		if err != nil {
+			setupLog.Error(err, "unable to set up notifications")
+			os.Exit(1)
+		}
+		mgr.GetWebhookServer().Register("/notify", notificationHandler)
+	}
+
+	reloadDirs := []string{"/run/secrets"}
+	if api.locationsFile != "" {
+		reloadDirs = append(reloadDirs, path.Dir(api.locationsFile))
+	}
+	reloader, err := loadtesting.NewReloader(func() error {
+		return reloadAPIClients(api, apiClients)
+	}, reloadDirs...)
 	if err != nil {
-		setupLog.Error(err, "unable to set up pinger")
+		setupLog.Error(err, "unable to set up credentials reloader")
 		os.Exit(1)
 	}
-	if err = mgr.Add(pinger); err != nil {
-		setupLog.Error(err, "unable to set up pinger")
+	if err = mgr.Add(reloader); err != nil {
+		setupLog.Error(err, "unable to set up credentials reloader")
 		os.Exit(1)
-
 	}
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -0,0 +1,140 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+)
+
+// exitTimeout is returned by follow when it gave up waiting. It's never used as the exit code of the process.
+const exitTimeout = -1
+
+// follow polls a test run and prints every status and condition change, until all the locations finished. It
+// returns the exit code matching the outcome of the run.
+func (c *command) follow(ctx context.Context, name string, interval time.Duration, timeout time.Duration) int {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen := map[string]string{}
+	for {
+		run := &loadtestingapi.Run{}
+		err := c.api.Get(ctx, name, run)
+		switch {
+		case err == nil:
+			printChanges(c.out, run, seen)
+			if run.IsFinished() {
+				printRun(c.out, run)
+				return exitCode(run)
+			}
+		case client.IsRetryable(err):
+			// the API may be restarting, keep waiting
+			fmt.Fprintln(os.Stderr, "warning:", err)
+		default:
+			return fail(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr, "error: interrupted")
+			return exitFailed
+		case <-deadline:
+			fmt.Fprintf(os.Stderr, "error: %s didn't finish after %s\n", name, timeout)
+			return exitTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+// exitCode returns the exit code for a finished test run.
+func exitCode(run *loadtestingapi.Run) int {
+	switch run.Status() {
+	case loadtestingapi.STATUS_FAILED, loadtestingapi.STATUS_CANCELED:
+		return exitFailed
+	}
+
+	for _, location := range run.Locations {
+		job := loadtestingapi.Job{Conditions: location.Conditions}
+		if c := job.GetCondition(loadtestingapi.CONDITION_THRESHOLDS_PASSED); c != nil && c.Status == loadtestingapi.CONDITION_FALSE {
+			return exitThresholdsFailed
+		}
+	}
+
+	return exitOK
+}
+
+// printChanges prints a line for every location whose status or conditions changed since the last call. seen
+// holds what was printed already.
+func printChanges(out io.Writer, run *loadtestingapi.Run, seen map[string]string) {
+	now := time.Now().Format(time.TimeOnly)
+	for _, location := range run.Locations {
+		key := location.Location
+		status := fmt.Sprintf("%s (%d/%d workers)", location.Status, location.OnlineWorkers, location.Workers)
+		if location.StatusDescription != "" {
+			status += ": " + location.StatusDescription
+		}
+		if seen[key] != status {
+			fmt.Fprintf(out, "%s  %-12s %s\n", now, key, status)
+			seen[key] = status
+		}
+
+		for _, condition := range location.Conditions {
+			key := location.Location + "/" + condition.Type
+			value := condition.Status + " " + condition.Reason
+			if seen[key] == value {
+				continue
+			}
+			seen[key] = value
+
+			line := fmt.Sprintf("%s  %-12s %s=%s", now, location.Location, condition.Type, condition.Status)
+			if condition.Reason != "" {
+				line += " (" + condition.Reason + ")"
+			}
+			if condition.Message != "" {
+				line += ": " + condition.Message
+			}
+			fmt.Fprintln(out, line)
+		}
+	}
+}
+
+// printRun prints a summary of a test run.
+func printRun(out io.Writer, run *loadtestingapi.Run) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", run.Name)
+	fmt.Fprintf(w, "Status:\t%s\n", run.Status())
+	fmt.Fprintf(w, "Target:\t%s\n", run.Target)
+	fmt.Fprintf(w, "Started:\t%s\n", formatTime(run.StartedAt))
+	fmt.Fprintf(w, "Completed:\t%s\n", formatTime(run.CompletedAt))
+	if run.GrafanaURL != "" {
+		fmt.Fprintf(w, "Dashboard:\t%s\n", run.GrafanaURL)
+	}
+	_ = w.Flush()
+
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nLOCATION\tSTATUS\tWORKERS\tCONDITIONS\tDESCRIPTION")
+	for _, location := range run.Locations {
+		conditions := make([]string, 0, len(location.Conditions))
+		for _, condition := range location.Conditions {
+			conditions = append(conditions, condition.Type+"="+condition.Status)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n", location.Location, location.Status, location.OnlineWorkers,
+			location.Workers, strings.Join(conditions, ","), location.StatusDescription)
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,398 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+// orderly-ape is a command line client for the webapp API. It creates, starts and cancels test runs and follows
+// their progress, so tests can be run from scripts and CI pipelines.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+)
+
+// Exit codes. Threshold breaches use the same code as k6, so scripts can tell them apart from failed runs.
+const (
+	exitOK                = 0
+	exitFailed            = 1
+	exitUsage             = 2
+	exitThresholdsFailed  = 99
+	defaultPollInterval   = 5 * time.Second
+	defaultRequestTimeout = 30 * time.Second
+)
+
+const usage = `Usage: orderly-ape [global flags] <command> [flags] [args]
+
+Commands:
+  create -f FILE        create a draft test run from a YAML spec
+  start NAME            start a draft test run
+  cancel NAME           cancel a test run
+  get NAME              show a test run
+  list                  list test runs
+  locations             list testing locations and when they last checked in
+  watch NAME            follow the status of a test run until it finishes
+  run -f FILE           create and start a test run, optionally waiting for it
+
+Global flags:
+`
+
+// globalFlags holds the API settings. They can be set from the environment too.
+type globalFlags struct {
+	endpoint string
+	user     string
+	password string
+	token    string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	g := globalFlags{}
+	fs := flag.NewFlagSet("orderly-ape", flag.ContinueOnError)
+	fs.StringVar(&g.endpoint, "api-endpoint", firstNonEmpty(os.Getenv("ORDERLY_APE_API_ENDPOINT"), "http://localhost:8000/api/"),
+		"The webapp API endpoint (ORDERLY_APE_API_ENDPOINT).")
+	fs.StringVar(&g.user, "api-user", os.Getenv("ORDERLY_APE_API_USER"), "The API user (ORDERLY_APE_API_USER).")
+	fs.StringVar(&g.password, "api-password", os.Getenv("ORDERLY_APE_API_PASSWORD"),
+		"The API password (ORDERLY_APE_API_PASSWORD).")
+	fs.StringVar(&g.token, "api-token", os.Getenv("ORDERLY_APE_API_TOKEN"),
+		"A bearer token used instead of the user and password (ORDERLY_APE_API_TOKEN).")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	apiClient, err := newAPIClient(g)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return exitFailed
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cmd := &command{api: apiClient, out: os.Stdout}
+	name, cmdArgs := fs.Arg(0), fs.Args()[1:]
+	switch name {
+	case "create":
+		return cmd.create(ctx, cmdArgs)
+	case "start":
+		return cmd.start(ctx, cmdArgs)
+	case "cancel":
+		return cmd.cancel(ctx, cmdArgs)
+	case "get":
+		return cmd.get(ctx, cmdArgs)
+	case "list":
+		return cmd.list(ctx, cmdArgs)
+	case "locations":
+		return cmd.locations(ctx, cmdArgs)
+	case "watch":
+		return cmd.watch(ctx, cmdArgs)
+	case "run":
+		return cmd.run(ctx, cmdArgs)
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	fs.Usage()
+	return exitUsage
+}
+
+func newAPIClient(g globalFlags) (*client.UncachedClient, error) {
+	opts := []client.Option{
+		client.WithBaseURL(g.endpoint),
+		client.WithUsername(g.user),
+		client.WithPassword(g.password),
+		client.WithRetries(3, 500*time.Millisecond),
+	}
+	if g.token != "" {
+		opts = append(opts, client.WithAuthenticator(&client.BearerToken{Token: g.token}))
+	}
+
+	return client.NewUncachedClient(opts...)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// command runs the subcommands against the API.
+type command struct {
+	api *client.UncachedClient
+	out *os.File
+}
+
+// fail prints an error and returns the exit code for it.
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "error:", err)
+
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode() == 400 {
+		return exitUsage
+	}
+	return exitFailed
+}
+
+// parse parses the flags of a subcommand and checks the number of positional arguments.
+func parse(fs *flag.FlagSet, args []string, nargs int, argsUsage string) bool {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: orderly-ape %s [flags] %s\n", fs.Name(), argsUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+	if fs.NArg() != nargs {
+		fs.Usage()
+		return false
+	}
+	return true
+}
+
+// readSpec reads a test run from a YAML file. "-" reads from stdin.
+func readSpec(file string) (*loadtestingapi.Run, error) {
+	if file == "" {
+		return nil, errors.New("a spec file is required")
+	}
+
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	run := &loadtestingapi.Run{}
+	if err := yaml.UnmarshalStrict(data, run); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if len(run.Locations) == 0 {
+		return nil, fmt.Errorf("%s: at least one location is required", file)
+	}
+
+	return run, nil
+}
+
+func (c *command) create(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	file := fs.String("f", "", "The YAML spec of the test run, or - for stdin.")
+	if !parse(fs, args, 0, "-f FILE") {
+		return exitUsage
+	}
+
+	run, err := readSpec(*file)
+	if err != nil {
+		return fail(err)
+	}
+	if err := c.api.Create(ctx, run); err != nil {
+		return fail(err)
+	}
+
+	fmt.Fprintln(c.out, run.Name)
+	return exitOK
+}
+
+func (c *command) start(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("start", flag.ContinueOnError)
+	if !parse(fs, args, 1, "NAME") {
+		return exitUsage
+	}
+
+	run := &loadtestingapi.Run{Name: fs.Arg(0)}
+	if err := c.api.Action(ctx, run, "start", nil); err != nil {
+		return fail(err)
+	}
+
+	fmt.Fprintf(c.out, "%s started\n", run.Name)
+	return exitOK
+}
+
+func (c *command) cancel(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cancel", flag.ContinueOnError)
+	message := fs.String("message", "", "Why the test run was canceled.")
+	if !parse(fs, args, 1, "NAME") {
+		return exitUsage
+	}
+
+	run := &loadtestingapi.Run{Name: fs.Arg(0)}
+	var body map[string]string
+	if *message != "" {
+		body = map[string]string{"message": *message}
+	}
+	if err := c.api.Action(ctx, run, "cancel", body); err != nil {
+		return fail(err)
+	}
+
+	fmt.Fprintf(c.out, "%s canceled\n", run.Name)
+	return exitOK
+}
+
+func (c *command) get(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	output := fs.String("o", "", "The output format: empty for a summary, or yaml.")
+	if !parse(fs, args, 1, "NAME") {
+		return exitUsage
+	}
+
+	run := &loadtestingapi.Run{}
+	if err := c.api.Get(ctx, fs.Arg(0), run); err != nil {
+		return fail(err)
+	}
+
+	if *output == "yaml" {
+		data, err := yaml.Marshal(run)
+		if err != nil {
+			return fail(err)
+		}
+		_, _ = c.out.Write(data)
+		return exitOK
+	}
+
+	printRun(c.out, run)
+	return exitOK
+}
+
+func (c *command) list(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	if !parse(fs, args, 0, "") {
+		return exitUsage
+	}
+
+	runs := &loadtestingapi.RunList{}
+	if err := c.api.List(ctx, runs); err != nil {
+		return fail(err)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tTARGET\tLOCATIONS\tSTARTED\tCOMPLETED")
+	for _, run := range runs.Items {
+		locations := make([]string, 0, len(run.Locations))
+		for _, location := range run.Locations {
+			locations = append(locations, location.Location)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", run.Name, run.Status(), run.Target, strings.Join(locations, ","),
+			formatTime(run.StartedAt), formatTime(run.CompletedAt))
+	}
+	_ = w.Flush()
+
+	return exitOK
+}
+
+func (c *command) locations(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("locations", flag.ContinueOnError)
+	if !parse(fs, args, 0, "") {
+		return exitUsage
+	}
+
+	locations := &loadtestingapi.LocationList{}
+	if err := c.api.List(ctx, locations); err != nil {
+		return fail(err)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDISPLAY NAME\tONLINE\tLAST PING")
+	for _, location := range locations.Items {
+		lastPing := "never"
+		if location.LastPing != nil {
+			lastPing = fmt.Sprintf("%s (%s ago)", formatTime(location.LastPing),
+				time.Since(*location.LastPing).Truncate(time.Second))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", location.Name, location.DisplayName, location.Online, lastPing)
+	}
+	_ = w.Flush()
+
+	return exitOK
+}
+
+func (c *command) watch(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := fs.Duration("interval", defaultPollInterval, "How often the test run is checked.")
+	timeout := fs.Duration("timeout", 0, "Give up waiting after this long. Zero waits forever.")
+	if !parse(fs, args, 1, "NAME") {
+		return exitUsage
+	}
+
+	return c.follow(ctx, fs.Arg(0), *interval, *timeout)
+}
+
+func (c *command) run(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	file := fs.String("f", "", "The YAML spec of the test run, or - for stdin.")
+	wait := fs.Bool("wait", false, "Wait for the test run to finish. The exit code is 1 if it failed or was canceled, "+
+		"and 99 if thresholds were crossed.")
+	interval := fs.Duration("interval", defaultPollInterval, "How often the test run is checked while waiting.")
+	timeout := fs.Duration("timeout", 0, "Cancel the test run if it didn't finish after this long. Zero waits forever.")
+	if !parse(fs, args, 0, "-f FILE") {
+		return exitUsage
+	}
+
+	run, err := readSpec(*file)
+	if err != nil {
+		return fail(err)
+	}
+	if err := c.api.Create(ctx, run); err != nil {
+		return fail(err)
+	}
+	if err := c.api.Action(ctx, run, "start", nil); err != nil {
+		return fail(err)
+	}
+	fmt.Fprintf(c.out, "%s started\n", run.Name)
+
+	if !*wait {
+		return exitOK
+	}
+
+	code := c.follow(ctx, run.Name, *interval, *timeout)
+	if ctx.Err() != nil || code == exitTimeout {
+		// don't leave a test running after we stopped waiting for it
+		cancelCtx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+		defer cancel()
+		message := "Canceled by orderly-ape"
+		if code == exitTimeout {
+			message = fmt.Sprintf("Canceled by orderly-ape after %s", *timeout)
+		}
+		if err := c.api.Action(cancelCtx, &loadtestingapi.Run{Name: run.Name}, "cancel",
+			map[string]string{"message": message}); err != nil {
+			fmt.Fprintln(os.Stderr, "error: failed canceling test run:", err)
+		}
+		return exitFailed
+	}
+
+	return code
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
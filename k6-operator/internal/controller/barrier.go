@@ -0,0 +1,58 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+// checkStartBarrier waits for all the locations of a running test to confirm they started. If the test run is
+// aborted when incomplete and the confirmation window is over, k6 is stopped in all the pods and the job fails.
+// It returns how long to wait before checking again, zero if there's nothing to wait for, and whether the
+// conditions changed.
+func (r *TestRunReconciler) checkStartBarrier(ctx context.Context, job *loadtestingapi.Job, pods []corev1.Pod) (time.Duration, bool) {
+	if job.TestRun.Confirmed {
+		return 0, job.SetCondition(loadtestingapi.CONDITION_START_CONFIRMED, loadtestingapi.CONDITION_TRUE,
+			"AllLocationsStarted", "All the locations confirmed they started")
+	}
+	if !job.TestRun.AbortIfIncomplete || job.TestRun.StartTestAt == nil {
+		return 0, false
+	}
+
+	window := job.TestRun.GetStartConfirmationWindow()
+	// the deadline is expressed in the API clock, so we correct it with the measured offset
+	deadline := job.ConfirmationDeadline().Add(-r.clockOffset())
+	if remaining := time.Until(deadline); remaining > 0 {
+		return remaining, job.SetCondition(loadtestingapi.CONDITION_START_CONFIRMED, loadtestingapi.CONDITION_FALSE,
+			"WaitingForLocations", fmt.Sprintf("Waiting until %s for all the locations to start", deadline.UTC().Format(time.RFC3339)))
+	}
+
+	l := log.FromContext(ctx)
+	l.Info("Not all the locations started in time, stopping the test", "window", window)
+
+	podNames := []string{}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodRunning {
+			podNames = append(podNames, pod.Name)
+		}
+	}
+
+	description := fmt.Sprintf("Not all the locations started within %s, the test was stopped", window)
+	if err := r.stopPods(ctx, job.GetNamespace(), podNames); err != nil {
+		l.Error(err, "Failed stopping the test")
+		description = fmt.Sprintf("Not all the locations started within %s, failed stopping the test: %s", window, err)
+	}
+
+	job.Status = loadtestingapi.STATUS_FAILED
+	job.StatusDescription = description
+	return 0, job.SetCondition(loadtestingapi.CONDITION_START_CONFIRMED, loadtestingapi.CONDITION_FALSE, "Incomplete",
+		fmt.Sprintf("Not all the locations started within %s", window))
+}
@@ -0,0 +1,97 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	k6fake "github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/fake"
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+var _ = Describe("Start barrier", func() {
+	var (
+		ctx        context.Context
+		job        *loadtestingapi.Job
+		k6         *k6fake.K6
+		pods       []corev1.Pod
+		reconciler *TestRunReconciler
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		startAt := time.Now().Add(-time.Minute)
+		job = &loadtestingapi.Job{
+			Name:   "test-run",
+			Status: loadtestingapi.STATUS_RUNNING,
+			TestRun: loadtestingapi.TestRun{
+				StartTestAt:             &startAt,
+				AbortIfIncomplete:       true,
+				StartConfirmationWindow: &loadtestingapi.Duration{Duration: 2 * time.Minute},
+			},
+		}
+
+		k6 = k6fake.New(5)
+		dialer := k6fake.NewDialer()
+		dialer.Add(job.GetNamespace(), "pod-0", k6)
+		pods = []corev1.Pod{{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-0"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}}
+		reconciler = &TestRunReconciler{PodDialer: dialer}
+
+		backoff := igniteBackoff
+		igniteBackoff = wait.Backoff{Duration: time.Millisecond, Steps: 3}
+		DeferCleanup(func() { igniteBackoff = backoff })
+	})
+
+	It("waits for the other locations until the window is over", func() {
+		requeueAfter, changed := reconciler.checkStartBarrier(ctx, job, pods)
+
+		Expect(changed).To(BeTrue())
+		Expect(requeueAfter).To(BeNumerically("~", time.Minute, time.Second))
+		Expect(job.Status).To(Equal(loadtestingapi.STATUS_RUNNING))
+		Expect(job.GetCondition(loadtestingapi.CONDITION_START_CONFIRMED).Reason).To(Equal("WaitingForLocations"))
+	})
+
+	It("confirms the start once all the locations started", func() {
+		job.TestRun.Confirmed = true
+
+		requeueAfter, _ := reconciler.checkStartBarrier(ctx, job, pods)
+
+		Expect(requeueAfter).To(BeZero())
+		Expect(job.IsConditionTrue(loadtestingapi.CONDITION_START_CONFIRMED)).To(BeTrue())
+	})
+
+	It("stops the test when not all the locations started in time", func() {
+		job.TestRun.StartConfirmationWindow.Duration = 30 * time.Second
+
+		requeueAfter, changed := reconciler.checkStartBarrier(ctx, job, pods)
+
+		Expect(changed).To(BeTrue())
+		Expect(requeueAfter).To(BeZero())
+		Expect(job.Status).To(Equal(loadtestingapi.STATUS_FAILED))
+		Expect(job.StatusDescription).To(ContainSubstring("Not all the locations started within 30s"))
+		Expect(job.GetCondition(loadtestingapi.CONDITION_START_CONFIRMED).Reason).To(Equal("Incomplete"))
+		Expect(*k6.Status().Stopped).To(BeTrue())
+	})
+
+	It("doesn't wait when the barrier is disabled", func() {
+		job.TestRun.AbortIfIncomplete = false
+		job.TestRun.StartConfirmationWindow.Duration = 30 * time.Second
+
+		requeueAfter, changed := reconciler.checkStartBarrier(ctx, job, pods)
+
+		Expect(changed).To(BeFalse())
+		Expect(requeueAfter).To(BeZero())
+		Expect(job.Status).To(Equal(loadtestingapi.STATUS_RUNNING))
+	})
+})
@@ -0,0 +1,181 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+// k6ThresholdsFailedExitCode is the exit code of k6 when thresholds have failed.
+const k6ThresholdsFailedExitCode = 99
+
+// updatePodConditions updates the conditions observed on the worker pods of a job. It returns true if any of them
+// changed.
+func updatePodConditions(job *loadtestingapi.Job, pods []corev1.Pod) bool {
+	changed := false
+	// phases which were completed stay so, even if their pods are gone
+	if !job.IsConditionTrue(loadtestingapi.CONDITION_SCRIPT_FETCHED) {
+		changed = updateScriptFetched(job, pods) || changed
+	}
+	if !job.IsConditionTrue(loadtestingapi.CONDITION_PODS_SCHEDULED) {
+		changed = updatePodsScheduled(job, pods) || changed
+	}
+
+	switch job.Status {
+	case loadtestingapi.STATUS_QUEUED:
+		changed = updatePodsReady(job, pods) || changed
+	case loadtestingapi.STATUS_RUNNING:
+		changed = updateMetricsFlushing(job, pods) || changed
+		changed = updateThresholdsPassed(job, pods) || changed
+	}
+
+	return changed
+}
+
+func updateScriptFetched(job *loadtestingapi.Job, pods []corev1.Pod) bool {
+	fetched := 0
+	for _, pod := range pods {
+		status := getContainerStatus(pod.Status.InitContainerStatuses, "git")
+		if status == nil || status.State.Terminated == nil {
+			continue
+		}
+		if status.State.Terminated.ExitCode != 0 {
+			return job.SetCondition(loadtestingapi.CONDITION_SCRIPT_FETCHED, loadtestingapi.CONDITION_FALSE, "FetchFailed",
+				fmt.Sprintf("Pod %s failed fetching the script with code %d", pod.Name, status.State.Terminated.ExitCode))
+		}
+		fetched++
+	}
+
+	if fetched == len(job.AssignedSegments) {
+		return job.SetCondition(loadtestingapi.CONDITION_SCRIPT_FETCHED, loadtestingapi.CONDITION_TRUE, "Fetched",
+			fmt.Sprintf("Script %s fetched by all pods", job.TestRun.SourceRef))
+	}
+	return job.SetCondition(loadtestingapi.CONDITION_SCRIPT_FETCHED, loadtestingapi.CONDITION_FALSE, "Fetching",
+		fmt.Sprintf("Script fetched by %d/%d pods", fetched, len(job.AssignedSegments)))
+}
+
+func updatePodsScheduled(job *loadtestingapi.Job, pods []corev1.Pod) bool {
+	scheduled := 0
+	for _, pod := range pods {
+		condition := getPodCondition(&pod, corev1.PodScheduled)
+		if condition == nil {
+			continue
+		}
+		if condition.Status == corev1.ConditionTrue {
+			scheduled++
+			continue
+		}
+		if condition.Reason == corev1.PodReasonUnschedulable {
+			return job.SetCondition(loadtestingapi.CONDITION_PODS_SCHEDULED, loadtestingapi.CONDITION_FALSE, condition.Reason,
+				fmt.Sprintf("Pod %s can't be scheduled: %s", pod.Name, condition.Message))
+		}
+	}
+
+	if scheduled == len(job.AssignedSegments) {
+		return job.SetCondition(loadtestingapi.CONDITION_PODS_SCHEDULED, loadtestingapi.CONDITION_TRUE, "Scheduled",
+			"All pods were scheduled")
+	}
+	return job.SetCondition(loadtestingapi.CONDITION_PODS_SCHEDULED, loadtestingapi.CONDITION_FALSE, "Pending",
+		fmt.Sprintf("%d/%d pods scheduled", scheduled, len(job.AssignedSegments)))
+}
+
+func updatePodsReady(job *loadtestingapi.Job, pods []corev1.Pod) bool {
+	ready := 0
+	for _, pod := range pods {
+		if isPodStableReady(&pod) {
+			ready++
+		}
+	}
+
+	if len(pods) == len(job.AssignedSegments) && ready == len(pods) {
+		return job.SetCondition(loadtestingapi.CONDITION_PODS_READY, loadtestingapi.CONDITION_TRUE, "Ready",
+			"All pods are ready")
+	}
+	return job.SetCondition(loadtestingapi.CONDITION_PODS_READY, loadtestingapi.CONDITION_FALSE, "NotReady",
+		fmt.Sprintf("%d/%d pods ready", ready, len(job.AssignedSegments)))
+}
+
+// updateMetricsFlushing checks if k6 is done on any pod. The k6 API stops answering when k6 exits, so the pod
+// isn't ready anymore, while the container waits for telegraf to flush the metrics.
+func updateMetricsFlushing(job *loadtestingapi.Job, pods []corev1.Pod) bool {
+	completed, flushing := 0, 0
+	for _, pod := range pods {
+		if isPodCompleted(&pod) {
+			completed++
+			continue
+		}
+
+		status := getContainerStatus(pod.Status.ContainerStatuses, "k6")
+		if status != nil && status.State.Running != nil && !status.Ready {
+			flushing++
+		}
+	}
+
+	switch {
+	case completed == len(job.AssignedSegments):
+		return job.SetCondition(loadtestingapi.CONDITION_METRICS_FLUSHING, loadtestingapi.CONDITION_FALSE, "Flushed",
+			"Metrics were flushed by all pods")
+	case flushing > 0:
+		return job.SetCondition(loadtestingapi.CONDITION_METRICS_FLUSHING, loadtestingapi.CONDITION_TRUE, "Flushing",
+			fmt.Sprintf("%d/%d pods are flushing metrics", flushing+completed, len(job.AssignedSegments)))
+	case completed == 0:
+		return job.SetCondition(loadtestingapi.CONDITION_METRICS_FLUSHING, loadtestingapi.CONDITION_FALSE, "Testing",
+			"k6 is running")
+	}
+	return false
+}
+
+// updateThresholdsPassed reads the exit codes of k6, which the k6 container writes as its termination message.
+func updateThresholdsPassed(job *loadtestingapi.Job, pods []corev1.Pod) bool {
+	passed, failed := 0, 0
+	for _, pod := range pods {
+		status := getContainerStatus(pod.Status.ContainerStatuses, "k6")
+		if status == nil || status.State.Terminated == nil {
+			continue
+		}
+
+		code, err := strconv.Atoi(strings.TrimSpace(status.State.Terminated.Message))
+		switch {
+		case err != nil:
+		case code == k6ThresholdsFailedExitCode:
+			failed++
+		case code == 0:
+			passed++
+		}
+	}
+
+	if failed > 0 {
+		return job.SetCondition(loadtestingapi.CONDITION_THRESHOLDS_PASSED, loadtestingapi.CONDITION_FALSE, "ThresholdsFailed",
+			fmt.Sprintf("Thresholds failed on %d/%d pods", failed, len(job.AssignedSegments)))
+	}
+	if passed == len(job.AssignedSegments) {
+		return job.SetCondition(loadtestingapi.CONDITION_THRESHOLDS_PASSED, loadtestingapi.CONDITION_TRUE, "Passed",
+			"Thresholds passed on all pods")
+	}
+	return false
+}
+
+func getContainerStatus(statuses []corev1.ContainerStatus, name string) *corev1.ContainerStatus {
+	for idx := range statuses {
+		if statuses[idx].Name == name {
+			return &statuses[idx]
+		}
+	}
+	return nil
+}
+
+func getPodCondition(pod *corev1.Pod, conditionType corev1.PodConditionType) *corev1.PodCondition {
+	for idx := range pod.Status.Conditions {
+		if pod.Status.Conditions[idx].Type == conditionType {
+			return &pod.Status.Conditions[idx]
+		}
+	}
+	return nil
+}
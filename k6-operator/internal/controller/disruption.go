@@ -0,0 +1,129 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+// isPodDisrupted returns true if a pod is, or was, evicted by a node disruption (drain, preemption, ...). The
+// Kubernetes Job doesn't count these pods as failed, it replaces them.
+func isPodDisrupted(pod *corev1.Pod) bool {
+	condition := getPodCondition(pod, corev1.DisruptionTarget)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+
+// withoutDisrupted returns the pods which weren't evicted by a node disruption.
+func withoutDisrupted(pods []corev1.Pod) []corev1.Pod {
+	result := make([]corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if !isPodDisrupted(&pod) {
+			result = append(result, pod)
+		}
+	}
+	return result
+}
+
+// hasDisruptedPods returns true if any of the pods was evicted by a node disruption.
+func hasDisruptedPods(pods []corev1.Pod) bool {
+	return len(withoutDisrupted(pods)) != len(pods)
+}
+
+// podIndex returns the completion index of a worker pod, which is the index of its segment, or -1 if unknown.
+func podIndex(pod *corev1.Pod) int {
+	idx, err := strconv.Atoi(pod.Annotations[batchv1.JobCompletionIndexAnnotation])
+	if err != nil {
+		return -1
+	}
+	return idx
+}
+
+// isPodIgnited returns true if the pod was un-paused when the job was ignited.
+func isPodIgnited(job *loadtestingapi.Job, pod *corev1.Pod) bool {
+	if job.Ignition == nil {
+		return false
+	}
+	return slices.ContainsFunc(job.Ignition.Pods, func(ignition loadtestingapi.PodIgnition) bool {
+		return ignition.Pod == pod.Name
+	})
+}
+
+// updateDisruptions records the segments whose worker pod was evicted by a node disruption after being un-paused.
+// k6 can't resume a segment, so these are lost, while pods evicted before the test started are transparently
+// replaced. It returns true if the disrupted segments or the Disrupted condition changed.
+func updateDisruptions(job *loadtestingapi.Job, pods []corev1.Pod) bool {
+	lost := make([]bool, len(job.AssignedSegments))
+	replaced := 0
+	for _, pod := range pods {
+		idx := podIndex(&pod)
+		if !isPodDisrupted(&pod) || idx < 0 || idx >= len(lost) {
+			continue
+		}
+		if isPodIgnited(job, &pod) {
+			lost[idx] = true
+		} else {
+			replaced++
+		}
+	}
+
+	var disrupted []loadtestingapi.Segment
+	for idx, segment := range job.AssignedSegments {
+		if lost[idx] {
+			disrupted = append(disrupted, segment)
+		}
+	}
+	changed := !slices.Equal(disrupted, job.DisruptedSegments)
+	job.DisruptedSegments = disrupted
+
+	switch {
+	case len(disrupted) > 0:
+		changed = job.SetCondition(loadtestingapi.CONDITION_DISRUPTED, loadtestingapi.CONDITION_TRUE, "SegmentsLost",
+			fmt.Sprintf("%d/%d segments were lost to node disruptions: %s", len(disrupted), len(lost),
+				describeSegments(disrupted))) || changed
+	case replaced > 0:
+		changed = job.SetCondition(loadtestingapi.CONDITION_DISRUPTED, loadtestingapi.CONDITION_TRUE, "PodsReplaced",
+			fmt.Sprintf("%d pods were evicted by node disruptions before the test started and were replaced", replaced)) || changed
+	}
+	return changed
+}
+
+// disruptedReplacements returns the pods replacing the ones of the disrupted segments. They were never un-paused,
+// and never will be, so they're stopped once the other pods are done.
+func disruptedReplacements(job *loadtestingapi.Job, pods []corev1.Pod) []string {
+	podNames := []string{}
+	for _, pod := range pods {
+		idx := podIndex(&pod)
+		if isPodCompleted(&pod) || isPodDisrupted(&pod) || isPodIgnited(job, &pod) || idx < 0 || idx >= len(job.AssignedSegments) {
+			continue
+		}
+		if slices.Contains(job.DisruptedSegments, job.AssignedSegments[idx]) {
+			podNames = append(podNames, pod.Name)
+		}
+	}
+	return podNames
+}
+
+// releaseDisruptedIgniter drops the igniter of a job if any of its pods was evicted before being un-paused, so it's
+// created again once the replacement pod is ready. It returns true if the igniter was released.
+func (r *TestRunReconciler) releaseDisruptedIgniter(job *loadtestingapi.Job, pods []corev1.Pod) bool {
+	igniter, found := r.igniters.Get(job.Name)
+	if !found {
+		return false
+	}
+
+	for _, pod := range pods {
+		if isPodDisrupted(&pod) && slices.Contains(igniter.PodNames, pod.Name) && igniter.Release() {
+			r.removeIgniter(job)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+func workerPod(name string, index int, phase corev1.PodPhase, disrupted bool) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			UID:         types.UID(name),
+			Annotations: map[string]string{batchv1.JobCompletionIndexAnnotation: strconv.Itoa(index)},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+	if disrupted {
+		pod.Status.Conditions = []corev1.PodCondition{{
+			Type:   corev1.DisruptionTarget,
+			Status: corev1.ConditionTrue,
+			Reason: "TerminationByKubelet",
+		}}
+	}
+	return pod
+}
+
+var _ = Describe("Node disruptions", func() {
+	var job *loadtestingapi.Job
+
+	BeforeEach(func() {
+		job = &loadtestingapi.Job{
+			Name:   "test-run",
+			Status: loadtestingapi.STATUS_RUNNING,
+			AssignedSegments: []loadtestingapi.Segment{
+				{ID: "1", Segment: "0:1/2"},
+				{ID: "2", Segment: "1/2:1"},
+			},
+			Ignition: &loadtestingapi.Ignition{
+				Pods: []loadtestingapi.PodIgnition{{Pod: "pod-0"}, {Pod: "pod-1"}},
+			},
+		}
+	})
+
+	It("loses the segments of the pods disrupted after the test started", func() {
+		pods := []corev1.Pod{
+			workerPod("pod-0", 0, corev1.PodRunning, false),
+			workerPod("pod-1", 1, corev1.PodFailed, true),
+			workerPod("pod-1-replacement", 1, corev1.PodRunning, false),
+		}
+
+		Expect(updateDisruptions(job, pods)).To(BeTrue())
+		Expect(job.DisruptedSegments).To(Equal([]loadtestingapi.Segment{{ID: "2", Segment: "1/2:1"}}))
+		Expect(job.GetCondition(loadtestingapi.CONDITION_DISRUPTED).Reason).To(Equal("SegmentsLost"))
+		Expect(disruptedReplacements(job, pods)).To(Equal([]string{"pod-1-replacement"}))
+		Expect(updateDisruptions(job, pods)).To(BeFalse())
+	})
+
+	It("replaces the pods disrupted before the test started", func() {
+		job.Status = loadtestingapi.STATUS_QUEUED
+		job.Ignition = nil
+		pods := []corev1.Pod{
+			workerPod("pod-0", 0, corev1.PodRunning, false),
+			workerPod("pod-1", 1, corev1.PodFailed, true),
+			workerPod("pod-1-replacement", 1, corev1.PodPending, false),
+		}
+
+		Expect(updateDisruptions(job, pods)).To(BeTrue())
+		Expect(job.DisruptedSegments).To(BeEmpty())
+		Expect(job.GetCondition(loadtestingapi.CONDITION_DISRUPTED).Reason).To(Equal("PodsReplaced"))
+		Expect(withoutDisrupted(pods)).To(HaveLen(2))
+	})
+
+	It("tells apart the segments lost to disruptions", func() {
+		job.TestRun.FailureTolerance = "1"
+		job.DisruptedSegments = []loadtestingapi.Segment{{ID: "2", Segment: "1/2:1"}}
+
+		completeJob(job, &batchv1.Job{Status: batchv1.JobStatus{CompletedIndexes: "0"}})
+
+		Expect(job.Status).To(Equal(loadtestingapi.STATUS_COMPLETED))
+		Expect(job.StatusDescription).To(ContainSubstring("1/2 segments were lost: 2 (1/2:1, disrupted)"))
+	})
+
+	It("creates the igniter again when one of its pods is disrupted before the start", func() {
+		reconciler := &TestRunReconciler{igniters: NewIgniters()}
+		_, cancel := context.WithCancel(context.Background())
+		igniter := &Igniter{Job: job, PodNames: []string{"pod-0", "pod-1"}, Cancel: cancel}
+		reconciler.igniters.Add(job.Name, igniter)
+		pods := []corev1.Pod{workerPod("pod-1", 1, corev1.PodFailed, true)}
+
+		Expect(reconciler.releaseDisruptedIgniter(job, pods)).To(BeTrue())
+		_, found := reconciler.igniters.Get(job.Name)
+		Expect(found).To(BeFalse())
+	})
+
+	It("keeps the igniter once the ignition began", func() {
+		reconciler := &TestRunReconciler{igniters: NewIgniters()}
+		_, cancel := context.WithCancel(context.Background())
+		igniter := &Igniter{Job: job, PodNames: []string{"pod-0", "pod-1"}, Cancel: cancel, igniting: true}
+		reconciler.igniters.Add(job.Name, igniter)
+		pods := []corev1.Pod{workerPod("pod-1", 1, corev1.PodFailed, true)}
+
+		Expect(reconciler.releaseDisruptedIgniter(job, pods)).To(BeFalse())
+		_, found := reconciler.igniters.Get(job.Name)
+		Expect(found).To(BeTrue())
+	})
+
+	It("counts every failed pod once, by cause", func() {
+		failures := NewPodFailures()
+		location := "disruption-test-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		pods := []corev1.Pod{
+			workerPod("pod-0", 0, corev1.PodFailed, false),
+			workerPod("pod-1", 1, corev1.PodRunning, true),
+		}
+
+		failures.Observe(location, job.Name, pods)
+		failures.Observe(location, job.Name, pods)
+
+		Expect(testutil.ToFloat64(workerPodFailures.WithLabelValues(location, failureCauseScript))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(workerPodFailures.WithLabelValues(location, failureCauseDisruption))).To(Equal(1.0))
+	})
+})
@@ -5,84 +5,347 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"golang.org/x/sync/errgroup"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	k6api "github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting"
 	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
 )
 
-type Igniters map[string]*Igniter
+var (
+	// igniteTimeout is the time allowed for all the pods to be started and verified.
+	igniteTimeout = 20 * time.Second
+
+	// persistTimeout is the time allowed for persisting the ignition state.
+	persistTimeout = 10 * time.Second
+
+	// igniteBackoff is the backoff used when retrying to start a single pod.
+	igniteBackoff = wait.Backoff{
+		Duration: 200 * time.Millisecond,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    6,
+		Cap:      3 * time.Second,
+	}
+)
 
 type Igniter struct {
-	*errgroup.Group
-	Started  bool
-	Error    error
+	// Job is a copy of the job taken when the igniter was created, as the reconciles keep updating theirs while the
+	// igniter runs.
 	Job      *loadtestingapi.Job
 	PodNames []string
 	Cancel   context.CancelFunc
-	groupCtx context.Context
+
+	mu        sync.Mutex
+	igniting  bool
+	started   bool
+	err       error
+	ignitions map[string]time.Time
+	lateness  time.Duration
+	notify    func(*Igniter)
 }
 
-//+kubebuilder:rbac:groups=core,resources=pods/proxy,verbs=get;post;put;patch;delete
+// State returns a snapshot of the igniter state.
+func (i *Igniter) State() IgniterState {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return IgniterState{
+		Started: i.started,
+		Error:   i.err,
+	}
+}
+
+// finish records the outcome of the ignition, persists it and notifies the controller.
+func (i *Igniter) finish(ctx context.Context, r *TestRunReconciler, err error) {
+	i.mu.Lock()
+	i.started = err == nil
+	i.err = err
+	notify := i.notify
+	i.mu.Unlock()
+
+	i.persist(ctx, r)
+
+	if notify != nil {
+		notify(i)
+	}
+}
+
+// persist persists the ignition state. It uses a fresh context, as the igniter one is cancelled when the igniter is
+// released or removed, which might happen while the outcome of the ignition is being recorded.
+func (i *Igniter) persist(ctx context.Context, r *TestRunReconciler) {
+	l := log.FromContext(ctx)
+	ctx, cancel := context.WithTimeout(ctrl.LoggerInto(context.Background(), l), persistTimeout)
+	defer cancel()
+
+	if err := r.persistIgnition(ctx, i); err != nil {
+		l.Error(err, "Failed persisting ignition state")
+	}
+}
 
 func (i *Igniter) Start(ctx context.Context, r *TestRunReconciler) error {
+	// StartAt is expressed in the API clock, so we correct it with the measured offset
+	startAt := i.Job.StartAt().Add(-r.clockOffset())
+
+	i.persist(ctx, r)
+
 	select {
 	case <-ctx.Done():
 		return nil
-	case <-time.After(time.Until(*i.Job.TestRun.StartTestAt)):
-		i.Started = true
+	case <-time.After(time.Until(startAt)):
 		l := log.FromContext(ctx)
-		l.Info("Starting test runs")
-
-		for _, podName := range i.PodNames {
-			pod := &corev1.Pod{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      podName,
-					Namespace: i.Job.GetNamespace(),
-				},
+
+		// the igniter might have been released while waiting
+		i.mu.Lock()
+		released := ctx.Err() != nil
+		i.igniting = !released
+		i.mu.Unlock()
+		if released {
+			return nil
+		}
+
+		if err := r.checkClockSkew(); err != nil {
+			l.Error(err, "Refusing to start test runs")
+			i.finish(ctx, r, err)
+			return err
+		}
+
+		if err := i.checkLateness(ctx, r.clockOffset()); err != nil {
+			l.Error(err, "Refusing to start test runs")
+			i.finish(ctx, r, err)
+			return err
+		}
+
+		l.Info("Starting test runs", "clockOffset", r.clockOffset(), "lateness", i.lateness)
+
+		igniteCtx, cancel := context.WithTimeout(ctx, igniteTimeout+i.Job.GetIgnitionStagger())
+		defer cancel()
+
+		err := i.ignite(igniteCtx, r)
+		if err == nil {
+			err = i.verify(igniteCtx, r)
+		}
+
+		if err != nil {
+			l.Error(err, "Failed starting test runs, stopping already started pods")
+			// use a fresh context, as the ignition one might be expired
+			if stopErr := i.rollback(ctrl.LoggerInto(context.Background(), l), r); stopErr != nil {
+				err = errors.Join(err, stopErr)
 			}
+			i.finish(ctx, r, err)
+			return err
+		}
+
+		i.finish(ctx, r, nil)
+		l.Info("Test runs started", "spread", i.Report(0).Spread)
+	}
+	return nil
+}
+
+// checkLateness records how late the ignition is and applies the late start policy of the test run.
+func (i *Igniter) checkLateness(ctx context.Context, offset time.Duration) error {
+	lateness := time.Now().Add(offset).Sub(i.Job.StartAt())
+	if lateness < 0 {
+		lateness = 0
+	}
+
+	i.mu.Lock()
+	i.lateness = lateness
+	i.mu.Unlock()
+
+	// the other locations give up on this one once the confirmation window is over
+	ignitedAt := time.Now().Add(offset + i.Job.GetIgnitionStagger())
+	if i.Job.TestRun.AbortIfIncomplete && ignitedAt.After(i.Job.ConfirmationDeadline()) {
+		return fmt.Errorf("missed start confirmation window: started %s late, window is %s", lateness.Round(time.Millisecond),
+			i.Job.TestRun.GetStartConfirmationWindow())
+	}
 
-			i.Go(func() error {
-				l.Info("IGNITE", "pod", pod.Name)
-				client := r.clientset.CoreV1().RESTClient()
-				status := k6api.StatusRequest{
-					Data: k6api.StatusData{
-						ID:   "default",
-						Type: "status",
-						Attributes: k6api.StatusAttributes{
-							Paused: falsePtr,
-						},
-					},
+	tolerance := i.Job.TestRun.GetLateStartTolerance()
+	if lateness <= tolerance {
+		return nil
+	}
+
+	if i.Job.TestRun.GetLateStartPolicy() == loadtestingapi.LATE_START_FAIL {
+		return fmt.Errorf("missed start window: started %s late, tolerance is %s", lateness.Round(time.Millisecond), tolerance)
+	}
+
+	log.FromContext(ctx).Info("Starting late", "lateness", lateness, "tolerance", tolerance)
+	return nil
+}
+
+// ignite un-pauses all the pods, retrying each of them until igniteTimeout is reached. With an ignition stagger,
+// the pods are un-paused one after the other, evenly spread over it. Once a pod fails to start, the pods not started
+// yet are left paused.
+func (i *Igniter) ignite(ctx context.Context, r *TestRunReconciler) error {
+	l := log.FromContext(ctx)
+	g, ctx := errgroup.WithContext(ctx)
+
+	for idx, podName := range i.PodNames {
+		podName := podName
+		delay := i.staggerDelay(idx)
+		g.Go(func() error {
+			if delay > 0 {
+				select {
+				case <-ctx.Done():
+					return fmt.Errorf("starting pod %s: %w", podName, ctx.Err())
+				case <-time.After(delay):
 				}
-				statusObj, _ := json.Marshal(status)
-
-				resp, err := client.Patch("application/json").
-					Resource("pods").
-					SubResource("proxy").
-					Namespace(pod.Namespace).
-					Name(pod.Name).
-					Suffix("/v1/status").
-					Body(statusObj).
-					DoRaw(i.groupCtx)
-				l.Info("STATUS UPDATE", "resp", string(resp), "body", string(statusObj))
+			}
 
+			err := retry.OnError(igniteBackoff, func(error) bool { return ctx.Err() == nil }, func() error {
+				l.Info("IGNITE", "pod", podName)
+				_, err := r.patchK6Status(ctx, i.Job.GetNamespace(), podName, k6api.StatusAttributes{
+					Paused: falsePtr,
+				})
+				if err != nil {
+					l.Error(err, "Failed starting pod", "pod", podName)
+				}
 				return err
 			})
-		}
+			if err != nil {
+				return fmt.Errorf("starting pod %s: %w", podName, err)
+			}
 
-		if err := i.Wait(); err != nil {
-			i.Error = err
-			return err
-		}
+			i.mu.Lock()
+			defer i.mu.Unlock()
+			i.ignitions[podName] = time.Now()
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// staggerDelay returns how long after the start of the ignition the pod at index idx is un-paused.
+func (i *Igniter) staggerDelay(idx int) time.Duration {
+	stagger := i.Job.GetIgnitionStagger()
+	if stagger == 0 || len(i.PodNames) < 2 {
+		return 0
+	}
+	return stagger * time.Duration(idx) / time.Duration(len(i.PodNames)-1)
+}
+
+// verify confirms that k6 reports all the pods as running.
+func (i *Igniter) verify(ctx context.Context, r *TestRunReconciler) error {
+	g := errgroup.Group{}
+
+	for _, podName := range i.PodNames {
+		podName := podName
+		g.Go(func() error {
+			return retry.OnError(igniteBackoff, func(error) bool { return ctx.Err() == nil }, func() error {
+				status, err := r.getK6Status(ctx, i.Job.GetNamespace(), podName)
+				if err != nil {
+					return fmt.Errorf("verifying pod %s: %w", podName, err)
+				}
+				if !status.IsRunning() {
+					return fmt.Errorf("pod %s is not running", podName)
+				}
+				return nil
+			})
+		})
+	}
+
+	return g.Wait()
+}
+
+// rollback stops all the pods. Not only the ones known to be started, as k6 might have started in pods whose
+// request failed or timed out.
+func (i *Igniter) rollback(ctx context.Context, r *TestRunReconciler) error {
+	return r.stopPods(ctx, i.Job.GetNamespace(), i.PodNames)
+}
+
+// stopPods stops k6 in all the given pods, retrying each of them until igniteTimeout is reached.
+func (r *TestRunReconciler) stopPods(ctx context.Context, namespace string, podNames []string) error {
+	l := log.FromContext(ctx)
+	g := errgroup.Group{}
+
+	ctx, cancel := context.WithTimeout(ctx, igniteTimeout)
+	defer cancel()
+
+	for _, podName := range podNames {
+		podName := podName
+		g.Go(func() error {
+			return retry.OnError(igniteBackoff, func(error) bool { return ctx.Err() == nil }, func() error {
+				l.Info("STOP", "pod", podName)
+				_, err := r.patchK6Status(ctx, namespace, podName, k6api.StatusAttributes{
+					Stopped: truePtr,
+				})
+				if err != nil {
+					return fmt.Errorf("stopping pod %s: %w", podName, err)
+				}
+				return nil
+			})
+		})
+	}
+
+	return g.Wait()
+}
+
+// Report returns the ignition timestamps of all the started pods. offset is added to every timestamp, in order to
+// report them in the API clock.
+func (i *Igniter) Report(offset time.Duration) *loadtestingapi.Ignition {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	report := &loadtestingapi.Ignition{
+		Pods:     make([]loadtestingapi.PodIgnition, 0, len(i.ignitions)),
+		Lateness: loadtestingapi.Duration{Duration: i.lateness},
 	}
+	for podName, ignitedAt := range i.ignitions {
+		report.Pods = append(report.Pods, loadtestingapi.PodIgnition{
+			Pod:       podName,
+			IgnitedAt: ignitedAt.Add(offset),
+		})
+	}
+	sort.Slice(report.Pods, func(a, b int) bool {
+		return report.Pods[a].IgnitedAt.Before(report.Pods[b].IgnitedAt)
+	})
+
+	if len(report.Pods) > 0 {
+		report.Spread.Duration = report.Pods[len(report.Pods)-1].IgnitedAt.Sub(report.Pods[0].IgnitedAt)
+	}
+
+	return report
+}
+
+// describeIgnition describes the spread of an ignition and its lateness, if any, ex. "within 12ms, 3.2s late".
+func describeIgnition(ignition *loadtestingapi.Ignition) string {
+	description := fmt.Sprintf("within %s", ignition.Spread.Round(time.Millisecond))
+	if lateness := ignition.Lateness.Round(time.Millisecond); lateness > 0 {
+		description = fmt.Sprintf("%s, %s late", description, lateness)
+	}
+	return description
+}
+
+// clockOffset returns the estimated API time minus the local time, or zero if it's unknown.
+func (r *TestRunReconciler) clockOffset() time.Duration {
+	clock, ok := r.APIClient.(loadtesting.ClockSource)
+	if !ok {
+		return 0
+	}
+
+	offset, _ := clock.ClockOffset()
+	return offset
+}
+
+// checkClockSkew returns an error if the measured clock skew exceeds the allowed maximum.
+func (r *TestRunReconciler) checkClockSkew() error {
+	offset := r.clockOffset()
+	if r.MaxClockSkew > 0 && offset.Abs() > r.MaxClockSkew {
+		return fmt.Errorf("clock skew of %s exceeds the maximum allowed %s", offset, r.MaxClockSkew)
+	}
+
 	return nil
 }
 
@@ -90,9 +353,22 @@ func (i *Igniter) Stop() {
 	i.Cancel()
 }
 
-func (r *TestRunReconciler) createIgniter(ctx context.Context, job *loadtestingapi.Job) (*Igniter, error) {
-	if _, found := r.igniters[job.Name]; found {
-		return r.igniters[job.Name], nil
+// Release stops the igniter if it didn't begin un-pausing the pods yet, so it can be created again for other pods.
+// It returns false if the ignition already began.
+func (i *Igniter) Release() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.igniting {
+		return false
+	}
+	i.Cancel()
+	return true
+}
+
+func (r *TestRunReconciler) createIgniter(ctx context.Context, job *loadtestingapi.Job, obj *batchv1.Job) (*Igniter, error) {
+	if igniter, found := r.igniters.Get(job.Name); found {
+		return igniter, nil
 	}
 
 	if !job.TestRun.Ready || job.TestRun.StartTestAt == nil {
@@ -103,6 +379,7 @@ func (r *TestRunReconciler) createIgniter(ctx context.Context, job *loadtestinga
 	if err != nil {
 		return nil, err
 	}
+	pods = withoutDisrupted(pods)
 	podNames := make([]string, len(pods))
 	for i, pod := range pods {
 		podNames[i] = pod.Name
@@ -116,16 +393,31 @@ func (r *TestRunReconciler) createIgniter(ctx context.Context, job *loadtestinga
 	// create a new context with logger
 	ctx = ctrl.LoggerInto(context.Background(), l)
 	ctx, cancel := context.WithCancel(ctx)
-	g, ctx := errgroup.WithContext(ctx)
 
 	igniter := &Igniter{
-		Job:      job,
-		Group:    g,
-		PodNames: podNames,
-		Cancel:   cancel,
-		groupCtx: ctx,
+		Job:       job.DeepCopy(),
+		PodNames:  podNames,
+		Cancel:    cancel,
+		ignitions: make(map[string]time.Time),
+	}
+
+	// The operator might have been restarted after this job was ignited
+	restored := restoreIgnition(obj, igniter)
+	if !restored && r.restoreIgnitionFromPods(ctx, obj, igniter) {
+		restored = true
+		if err := r.persistIgnition(ctx, igniter); err != nil {
+			l.Error(err, "Failed persisting ignition state")
+		}
+	}
+
+	igniter, added := r.igniters.Add(job.Name, igniter)
+	if !added || restored {
+		if restored {
+			l.Info("Restored ignition state", "state", igniter.State())
+		}
+		cancel()
+		return igniter, nil
 	}
-	r.igniters[job.Name] = igniter
 
 	go igniter.Start(ctx, r)
 
@@ -133,8 +425,5 @@ func (r *TestRunReconciler) createIgniter(ctx context.Context, job *loadtestinga
 }
 
 func (r *TestRunReconciler) removeIgniter(job *loadtestingapi.Job) {
-	if igniter, found := r.igniters[job.Name]; found {
-		igniter.Stop()
-	}
-	delete(r.igniters, job.Name)
+	r.igniters.Remove(job.Name)
 }
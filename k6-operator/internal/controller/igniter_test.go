@@ -0,0 +1,210 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	k6fake "github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/fake"
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/fake"
+)
+
+var _ = Describe("Igniter", func() {
+	var (
+		ctx        context.Context
+		dialer     *k6fake.Dialer
+		pods       []*k6fake.K6
+		igniter    *Igniter
+		reconciler *TestRunReconciler
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		job := &loadtestingapi.Job{Name: "test-run"}
+
+		dialer = k6fake.NewDialer()
+		pods = []*k6fake.K6{k6fake.New(5), k6fake.New(5)}
+		igniter = &Igniter{Job: job, PodNames: []string{"pod-0", "pod-1"}, ignitions: map[string]time.Time{}}
+		for idx, pod := range pods {
+			dialer.Add(job.GetNamespace(), igniter.PodNames[idx], pod)
+		}
+		reconciler = &TestRunReconciler{PodDialer: dialer}
+
+		backoff := igniteBackoff
+		igniteBackoff = wait.Backoff{Duration: time.Millisecond, Steps: 3}
+		DeferCleanup(func() { igniteBackoff = backoff })
+	})
+
+	It("un-pauses all the pods and verifies they are running", func() {
+		Expect(igniter.ignite(ctx, reconciler)).To(Succeed())
+		Expect(igniter.verify(ctx, reconciler)).To(Succeed())
+
+		for _, pod := range pods {
+			status := pod.Status()
+			Expect(status.IsRunning()).To(BeTrue())
+		}
+		Expect(igniter.Report(0).Pods).To(HaveLen(2))
+	})
+
+	It("spreads the start of the pods over the ignition stagger", func() {
+		igniter.Job.IgnitionStagger = &loadtestingapi.Duration{Duration: 200 * time.Millisecond}
+
+		Expect(igniter.ignite(ctx, reconciler)).To(Succeed())
+
+		report := igniter.Report(0)
+		Expect(report.Pods[0].Pod).To(Equal("pod-0"))
+		Expect(report.Spread.Duration).To(BeNumerically(">=", 200*time.Millisecond))
+	})
+
+	It("retries pods failing to start", func() {
+		pods[1].Fail(k6fake.Failure{Method: http.MethodPatch, Code: http.StatusServiceUnavailable, Times: 2})
+
+		Expect(igniter.ignite(ctx, reconciler)).To(Succeed())
+		status := pods[1].Status()
+		Expect(status.IsRunning()).To(BeTrue())
+	})
+
+	It("stops all the pods when one can't be started", func() {
+		// every attempt to start the pod fails, then it can be stopped
+		pods[1].Fail(k6fake.Failure{Method: http.MethodPatch, Code: http.StatusServiceUnavailable, Times: igniteBackoff.Steps})
+
+		Expect(igniter.ignite(ctx, reconciler)).NotTo(Succeed())
+		Expect(igniter.rollback(ctx, reconciler)).To(Succeed())
+
+		Expect(*pods[0].Status().Stopped).To(BeTrue())
+		Expect(*pods[1].Status().Stopped).To(BeTrue())
+	})
+
+	It("leaves the pods not started yet paused once one can't be started", func() {
+		igniter.Job.IgnitionStagger = &loadtestingapi.Duration{Duration: time.Minute}
+		pods[0].Fail(k6fake.Failure{Method: http.MethodPatch, Code: http.StatusServiceUnavailable})
+
+		started := time.Now()
+		Expect(igniter.ignite(ctx, reconciler)).NotTo(Succeed())
+		Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+		Expect(*pods[1].Status().Paused).To(BeTrue())
+	})
+
+	It("fails starting late with the fail policy", func() {
+		startAt := time.Now().Add(-time.Minute)
+		igniter.Job.TestRun.StartTestAt = &startAt
+		igniter.Job.TestRun.LateStartPolicy = loadtestingapi.LATE_START_FAIL
+
+		Expect(igniter.checkLateness(ctx, 0)).To(MatchError(ContainSubstring("missed start window")))
+		Expect(igniter.Report(0).Lateness.Duration).To(BeNumerically(">=", time.Minute))
+	})
+
+	It("starts late by default, and reports the lateness", func() {
+		startAt := time.Now().Add(-time.Minute)
+		igniter.Job.TestRun.StartTestAt = &startAt
+
+		Expect(igniter.checkLateness(ctx, 0)).To(Succeed())
+		Expect(describeIgnition(igniter.Report(0))).To(And(HavePrefix("within 0s, 1m0"), HaveSuffix("s late")))
+	})
+
+	It("persists its outcome even once it has been released", func() {
+		// unlike the fake client, the Kubernetes API client gives up on cancelled contexts
+		k8s := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).Build()
+		obj := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: igniter.Job.Name, Namespace: igniter.Job.GetNamespace()}}
+		Expect(k8s.Create(ctx, obj)).To(Succeed())
+		reconciler.Client = k8s
+		startAt := time.Now()
+		igniter.Job.TestRun.StartTestAt = &startAt
+
+		released, cancel := context.WithCancel(ctx)
+		cancel()
+		igniter.finish(released, reconciler, nil)
+
+		Expect(k8s.Get(ctx, types.NamespacedName{Name: obj.Name, Namespace: obj.Namespace}, obj)).To(Succeed())
+		Expect(obj.Annotations).To(HaveKey(annotationIgnitionCompletedAt))
+	})
+
+	It("doesn't verify pods which already finished", func() {
+		Expect(igniter.ignite(ctx, reconciler)).To(Succeed())
+		pods[0].Finish()
+
+		Expect(igniter.verify(ctx, reconciler)).To(MatchError(ContainSubstring("pod-0 is not running")))
+	})
+})
+
+var _ = Describe("Igniter while reconciling", func() {
+	const location = "igniter-race-test"
+
+	// Run with -race: the reconciles update their job in place while the igniter reads its own.
+	It("keeps its own copy of the job", func() {
+		ctx := context.Background()
+		api := fake.NewAPI()
+		api.StartDelay = 200 * time.Millisecond
+		Expect(api.AddRun(fake.Run{
+			Name: "ignited",
+			Locations: []fake.Location{{
+				Name:            location,
+				Workers:         2,
+				IgnitionStagger: &loadtestingapi.Duration{Duration: 100 * time.Millisecond},
+			}},
+		})).To(Succeed())
+		Expect(api.SetStatus(location, "ignited", loadtestingapi.STATUS_QUEUED, "")).To(Succeed())
+		Expect(api.SetStatus(location, "ignited", loadtestingapi.STATUS_READY, "")).To(Succeed())
+
+		apiClient := api.Client(location)
+		job := &loadtestingapi.Job{}
+		Expect(apiClient.Get(ctx, "ignited", job)).To(Succeed())
+
+		k8s := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+		Expect(k8s.Create(ctx, &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: job.Name, Namespace: job.GetNamespace()},
+			Status:     batchv1.JobStatus{Active: int32(len(job.AssignedSegments))},
+		})).To(Succeed())
+		dialer := k6fake.NewDialer()
+		for idx := range job.AssignedSegments {
+			pod := workerPod("ignited-"+strconv.Itoa(idx), idx, corev1.PodRunning, false)
+			pod.Namespace = job.GetNamespace()
+			pod.Labels = map[string]string{"batch.kubernetes.io/job-name": job.Name}
+			Expect(k8s.Create(ctx, &pod)).To(Succeed())
+			dialer.Add(job.GetNamespace(), pod.Name, k6fake.New(5))
+		}
+
+		reconciler := &TestRunReconciler{
+			Client:    k8s,
+			Location:  location,
+			APIClient: apiClient,
+			PodDialer: dialer,
+			igniters:  NewIgniters(),
+		}
+		DeferCleanup(func() { reconciler.removeIgniter(job) })
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: job.Name, Namespace: job.GetNamespace()}}
+		Eventually(func() (string, error) {
+			if _, err := reconciler.reconcileJob(ctx, req, job, false); err != nil {
+				// the API job changed, like the controller we need to get it again
+				return "", apiClient.Get(ctx, job.Name, job)
+			}
+			return job.Status, nil
+		}, 5*time.Second, 5*time.Millisecond).Should(Equal(loadtestingapi.STATUS_RUNNING))
+	})
+})
@@ -0,0 +1,83 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// IgniterState is a point in time snapshot of an igniter.
+type IgniterState struct {
+	Started bool
+	Error   error
+}
+
+// Done returns true if the igniter either started all the pods or failed.
+func (s IgniterState) Done() bool {
+	return s.Started || s.Error != nil
+}
+
+// Igniters keeps track of the running igniters, indexed by job name. It's safe for concurrent use.
+type Igniters struct {
+	// C receives an event every time an igniter finishes, so the job can be reconciled right away.
+	C chan event.GenericEvent
+
+	mu       sync.Mutex
+	igniters map[string]*Igniter
+}
+
+// NewIgniters instantiate an empty igniters registry.
+func NewIgniters() *Igniters {
+	return &Igniters{
+		C:        make(chan event.GenericEvent, 128),
+		igniters: make(map[string]*Igniter),
+	}
+}
+
+// Get returns the igniter for a job, if any.
+func (m *Igniters) Get(name string) (*Igniter, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	igniter, found := m.igniters[name]
+	return igniter, found
+}
+
+// Add registers an igniter, unless one already exists for the same job. It returns the registered igniter and
+// whether it was added.
+func (m *Igniters) Add(name string, igniter *Igniter) (*Igniter, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, found := m.igniters[name]; found {
+		return existing, false
+	}
+
+	igniter.notify = m.notify
+	m.igniters[name] = igniter
+	return igniter, true
+}
+
+// Remove stops and forgets the igniter of a job.
+func (m *Igniters) Remove(name string) {
+	m.mu.Lock()
+	igniter, found := m.igniters[name]
+	delete(m.igniters, name)
+	m.mu.Unlock()
+
+	if found {
+		igniter.Stop()
+	}
+}
+
+// notify enqueues a reconcile for the igniter's job. If the queue is full the event is dropped, as the job will be
+// picked up by the next poll anyway.
+func (m *Igniters) notify(igniter *Igniter) {
+	select {
+	case m.C <- event.GenericEvent{Object: igniter.Job.ToK8SResource()}:
+	default:
+	}
+}
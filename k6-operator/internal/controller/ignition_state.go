@@ -0,0 +1,115 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+// Annotations used to persist the ignition state on the Kubernetes Job, so it survives operator restarts.
+const (
+	annotationIgnitionScheduledAt = "orderly-ape.reviewsignal.com/ignition-scheduled-at"
+	annotationIgnitionPods        = "orderly-ape.reviewsignal.com/ignition-pods"
+	annotationIgnitionLateness    = "orderly-ape.reviewsignal.com/ignition-lateness"
+	annotationIgnitionCompletedAt = "orderly-ape.reviewsignal.com/ignition-completed-at"
+	annotationIgnitionError       = "orderly-ape.reviewsignal.com/ignition-error"
+)
+
+// persistIgnition stores the igniter state in the annotations of the Kubernetes Job.
+func (r *TestRunReconciler) persistIgnition(ctx context.Context, i *Igniter) error {
+	obj := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(i.Job.ToK8SResource()), obj); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(obj.DeepCopy())
+	if obj.Annotations == nil {
+		obj.Annotations = make(map[string]string)
+	}
+
+	obj.Annotations[annotationIgnitionScheduledAt] = i.Job.StartAt().Format(time.RFC3339Nano)
+
+	state := i.State()
+	if state.Done() {
+		report := i.Report(0)
+		pods, err := json.Marshal(report.Pods)
+		if err != nil {
+			return err
+		}
+		obj.Annotations[annotationIgnitionPods] = string(pods)
+		obj.Annotations[annotationIgnitionLateness] = report.Lateness.String()
+		obj.Annotations[annotationIgnitionCompletedAt] = time.Now().Format(time.RFC3339Nano)
+		if state.Error != nil {
+			obj.Annotations[annotationIgnitionError] = state.Error.Error()
+		}
+	}
+
+	return r.Patch(ctx, obj, patch)
+}
+
+// restoreIgnition rebuilds a finished igniter from the annotations of the Kubernetes Job. It returns false if the
+// ignition was not completed.
+func restoreIgnition(obj *batchv1.Job, igniter *Igniter) bool {
+	completedAt, found := obj.Annotations[annotationIgnitionCompletedAt]
+	if !found || completedAt == "" {
+		return false
+	}
+
+	pods := []loadtestingapi.PodIgnition{}
+	if raw, found := obj.Annotations[annotationIgnitionPods]; found {
+		_ = json.Unmarshal([]byte(raw), &pods)
+	}
+	for _, pod := range pods {
+		igniter.ignitions[pod.Pod] = pod.IgnitedAt
+	}
+
+	if raw, found := obj.Annotations[annotationIgnitionLateness]; found {
+		igniter.lateness, _ = time.ParseDuration(raw)
+	}
+
+	if msg, found := obj.Annotations[annotationIgnitionError]; found {
+		igniter.err = errors.New(msg)
+	} else {
+		igniter.started = true
+	}
+
+	return true
+}
+
+// restoreIgnitionFromPods checks the live k6 status of the pods of an interrupted ignition. If all of them are
+// already running, the igniter is marked as started.
+func (r *TestRunReconciler) restoreIgnitionFromPods(ctx context.Context, obj *batchv1.Job, igniter *Igniter) bool {
+	if _, found := obj.Annotations[annotationIgnitionScheduledAt]; !found {
+		return false
+	}
+
+	l := log.FromContext(ctx)
+	now := time.Now()
+	for _, podName := range igniter.PodNames {
+		status, err := r.getK6Status(ctx, obj.Namespace, podName)
+		if err != nil {
+			l.Error(err, "Failed retrieving k6 status while restoring ignition", "pod", podName)
+			return false
+		}
+		if !status.IsRunning() {
+			return false
+		}
+	}
+
+	// the actual ignition times were lost, so we use the time we found out about them
+	for _, podName := range igniter.PodNames {
+		igniter.ignitions[podName] = now
+	}
+	igniter.started = true
+	return true
+}
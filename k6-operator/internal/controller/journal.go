@@ -0,0 +1,270 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting"
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+// Annotations used to keep working while the API is unavailable.
+const (
+	// annotationJournal holds the last known state of the job, as JSON.
+	annotationJournal = "orderly-ape.reviewsignal.com/journal"
+	// annotationNotFound counts how many times in a row the API reported the job as missing.
+	annotationNotFound = "orderly-ape.reviewsignal.com/not-found"
+)
+
+const (
+	// offlineRequeueInterval is how often jobs are reconciled while the API is unavailable, as no changes are
+	// received from it.
+	offlineRequeueInterval = 15 * time.Second
+	// notFoundRequeueInterval is how long to wait before asking the API again about a missing job.
+	notFoundRequeueInterval = 10 * time.Second
+	// defaultNotFoundConfirmations is how many times the API needs to report a job as missing before it's deleted.
+	defaultNotFoundConfirmations = 3
+)
+
+// journal is the job state journaled on the Kubernetes Job. It only holds what's needed to keep going once the
+// Kubernetes Job exists: the annotation is readable by anyone allowed to get Jobs, so the output config, with its
+// InfluxDB token, and the env vars are never journaled, and it must not grow with the test run.
+type journal struct {
+	Name              string                     `json:"name"`
+	Status            string                     `json:"status"`
+	StatusDescription string                     `json:"status_description"`
+	AssignedSegments  []loadtestingapi.Segment   `json:"assigned_segments"`
+	Ignition          *loadtestingapi.Ignition   `json:"ignition,omitempty"`
+	Conditions        []loadtestingapi.Condition `json:"conditions,omitempty"`
+	LostSegments      []loadtestingapi.Segment   `json:"lost_segments,omitempty"`
+	DisruptedSegments []loadtestingapi.Segment   `json:"disrupted_segments,omitempty"`
+	UpdatedAt         string                     `json:"updated_at,omitempty"`
+	// StartOffset, IgnitionStagger and the start settings of the test run schedule and confirm the ignition
+	Ready                   bool                     `json:"ready,omitempty"`
+	Confirmed               bool                     `json:"confirmed,omitempty"`
+	StartOffset             *loadtestingapi.Duration `json:"start_offset,omitempty"`
+	IgnitionStagger         *loadtestingapi.Duration `json:"ignition_stagger,omitempty"`
+	StartTestAt             *time.Time               `json:"started_at,omitempty"`
+	LastStartOffset         *loadtestingapi.Duration `json:"last_start_offset,omitempty"`
+	StartConfirmationWindow *loadtestingapi.Duration `json:"start_confirmation_window,omitempty"`
+	AbortIfIncomplete       bool                     `json:"abort_if_incomplete,omitempty"`
+	LateStartPolicy         string                   `json:"late_start_policy,omitempty"`
+	LateStartTolerance      *loadtestingapi.Duration `json:"late_start_tolerance,omitempty"`
+	FailureTolerance        string                   `json:"failure_tolerance,omitempty"`
+	// Team is the label picking the namespace of the job in per-team namespace mode
+	Team string `json:"team,omitempty"`
+}
+
+// newJournal returns the state of a job to journal.
+func newJournal(job *loadtestingapi.Job) *journal {
+	return &journal{
+		Name:                    job.Name,
+		Status:                  job.Status,
+		StatusDescription:       job.StatusDescription,
+		AssignedSegments:        job.AssignedSegments,
+		Ignition:                job.Ignition,
+		Conditions:              job.Conditions,
+		LostSegments:            job.LostSegments,
+		DisruptedSegments:       job.DisruptedSegments,
+		UpdatedAt:               job.UpdatedAt,
+		StartOffset:             job.StartOffset,
+		IgnitionStagger:         job.IgnitionStagger,
+		Ready:                   job.TestRun.Ready,
+		Confirmed:               job.TestRun.Confirmed,
+		StartTestAt:             job.TestRun.StartTestAt,
+		LastStartOffset:         job.TestRun.LastStartOffset,
+		StartConfirmationWindow: job.TestRun.StartConfirmationWindow,
+		AbortIfIncomplete:       job.TestRun.AbortIfIncomplete,
+		LateStartPolicy:         job.TestRun.LateStartPolicy,
+		LateStartTolerance:      job.TestRun.LateStartTolerance,
+		FailureTolerance:        job.TestRun.FailureTolerance,
+		Team:                    job.TestRun.Labels[options.NamespaceTeamLabel],
+	}
+}
+
+// job returns the journaled job state.
+func (j *journal) job() *loadtestingapi.Job {
+	job := &loadtestingapi.Job{
+		Name:              j.Name,
+		Status:            j.Status,
+		StatusDescription: j.StatusDescription,
+		AssignedSegments:  j.AssignedSegments,
+		Ignition:          j.Ignition,
+		Conditions:        j.Conditions,
+		LostSegments:      j.LostSegments,
+		DisruptedSegments: j.DisruptedSegments,
+		UpdatedAt:         j.UpdatedAt,
+		StartOffset:       j.StartOffset,
+		IgnitionStagger:   j.IgnitionStagger,
+		TestRun: loadtestingapi.TestRun{
+			Ready:                   j.Ready,
+			Confirmed:               j.Confirmed,
+			StartTestAt:             j.StartTestAt,
+			LastStartOffset:         j.LastStartOffset,
+			StartConfirmationWindow: j.StartConfirmationWindow,
+			AbortIfIncomplete:       j.AbortIfIncomplete,
+			LateStartPolicy:         j.LateStartPolicy,
+			LateStartTolerance:      j.LateStartTolerance,
+			FailureTolerance:        j.FailureTolerance,
+		},
+	}
+	if j.Team != "" {
+		job.TestRun.Labels = map[string]string{options.NamespaceTeamLabel: j.Team}
+	}
+	return job
+}
+
+// getJob retrieves a job from the API. When the API is unavailable, the last known state of the job is returned
+// and offline is true. That's either the status update waiting to be replayed or the one journaled on the
+// Kubernetes Job.
+func (r *TestRunReconciler) getJob(ctx context.Context, key types.NamespacedName) (job *loadtestingapi.Job, offline bool, err error) {
+	job = &loadtestingapi.Job{}
+	err = r.APIClient.Get(ctx, key.Name, job)
+	if err == nil {
+		// A status update which failed earlier must reach the API before acting on the job again
+		if r.StatusQueue != nil && r.StatusQueue.Replay(ctx, job) != nil {
+			return job, true, nil
+		}
+		return job, false, nil
+	}
+
+	if !loadtesting.IsRetryable(err) {
+		return nil, false, err
+	}
+
+	journaled, found := r.readJournal(ctx, key)
+	if !found {
+		return nil, false, err
+	}
+
+	return journaled, true, nil
+}
+
+// readJournal returns the job state journaled on the Kubernetes Job.
+func (r *TestRunReconciler) readJournal(ctx context.Context, key types.NamespacedName) (*loadtestingapi.Job, bool) {
+	obj := &batchv1.Job{}
+	if err := r.Get(ctx, key, obj); err != nil {
+		return nil, false
+	}
+
+	job, err := journaledJob(obj)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed reading journaled job state")
+	}
+	if job == nil {
+		return nil, false
+	}
+	job.SetLocationName(r.Location)
+
+	return job, true
+}
+
+// journaledJob decodes the job state journaled on a Kubernetes Job. It returns nil if there is none.
+func journaledJob(obj *batchv1.Job) (*loadtestingapi.Job, error) {
+	raw, found := obj.Annotations[annotationJournal]
+	if !found {
+		return nil, nil
+	}
+
+	journaled := &journal{}
+	if err := json.Unmarshal([]byte(raw), journaled); err != nil {
+		return nil, err
+	}
+
+	return journaled.job(), nil
+}
+
+// restoreConditions copies the journaled conditions to a job the API returned without any, so their transition
+// times are kept.
+func restoreConditions(obj *batchv1.Job, job *loadtestingapi.Job) {
+	if len(job.Conditions) > 0 {
+		return
+	}
+	if journaled, _ := journaledJob(obj); journaled != nil {
+		job.Conditions = journaled.Conditions
+	}
+}
+
+// journalJob stores the job state on the Kubernetes Job, if it changed. It's a noop if the Kubernetes Job doesn't
+// exist yet.
+func (r *TestRunReconciler) journalJob(ctx context.Context, job *loadtestingapi.Job) error {
+	obj := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(job.ToK8SResource()), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	data, err := json.Marshal(newJournal(job))
+	if err != nil {
+		return err
+	}
+
+	_, notFound := obj.Annotations[annotationNotFound]
+	if obj.Annotations[annotationJournal] == string(data) && !notFound {
+		return nil
+	}
+
+	patch := client.MergeFrom(obj.DeepCopy())
+	if obj.Annotations == nil {
+		obj.Annotations = make(map[string]string)
+	}
+	obj.Annotations[annotationJournal] = string(data)
+	delete(obj.Annotations, annotationNotFound)
+
+	return r.Patch(ctx, obj, patch)
+}
+
+// confirmJobRemoval deletes the Kubernetes Job of a job missing from the API, once the API reported it missing
+// enough times in a row. A single 404 may be caused by a webapp glitch, and deleting a running test can't be
+// undone.
+func (r *TestRunReconciler) confirmJobRemoval(ctx context.Context, key types.NamespacedName) (ctrl.Result, error) {
+	obj := &batchv1.Job{}
+	if err := r.Get(ctx, key, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the Job is gone, so was its test run, and its namespace isn't needed anymore
+			return ctrl.Result{}, r.cleanupNamespace(ctx, key.Namespace)
+		}
+		return ctrl.Result{}, err
+	}
+	if obj.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
+	confirmations := r.NotFoundConfirmations
+	if confirmations <= 0 {
+		confirmations = defaultNotFoundConfirmations
+	}
+
+	count, _ := strconv.Atoi(obj.Annotations[annotationNotFound])
+	count++
+	if count < confirmations {
+		log.FromContext(ctx).Info("Job not found in API, waiting for confirmation", "name", key, "count", count)
+
+		patch := client.MergeFrom(obj.DeepCopy())
+		if obj.Annotations == nil {
+			obj.Annotations = make(map[string]string)
+		}
+		obj.Annotations[annotationNotFound] = strconv.Itoa(count)
+		return ctrl.Result{RequeueAfter: notFoundRequeueInterval}, r.Patch(ctx, obj, patch)
+	}
+
+	log.FromContext(ctx).Info("Job removed from API, deleting it", "name", key)
+	bgDelete := metav1.DeletePropagationBackground
+	err := r.Delete(ctx, obj, &client.DeleteOptions{
+		PropagationPolicy: &bgDelete,
+	})
+	return ctrl.Result{}, client.IgnoreNotFound(err)
+}
@@ -0,0 +1,92 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+var _ = Describe("Job journal", func() {
+	var (
+		ctx        context.Context
+		k8s        client.Client
+		reconciler *TestRunReconciler
+		job        *loadtestingapi.Job
+		obj        *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		k8s = clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+		reconciler = &TestRunReconciler{Client: k8s, Location: "journal-test"}
+
+		startAt := time.Now().Add(time.Minute).Truncate(time.Second)
+		job = &loadtestingapi.Job{
+			Name:              "journaled",
+			Status:            loadtestingapi.STATUS_READY,
+			StatusDescription: "Worker pods are ready and waiting to start testing",
+			AssignedSegments:  []loadtestingapi.Segment{{ID: "1", Segment: "0:1"}},
+			Conditions: []loadtestingapi.Condition{
+				{Type: loadtestingapi.CONDITION_PODS_READY, Status: loadtestingapi.CONDITION_TRUE},
+			},
+			TestRun: loadtestingapi.TestRun{
+				Ready:        true,
+				StartTestAt:  &startAt,
+				EnvVars:      map[string]string{"API_KEY": "env-secret"},
+				SourceScript: "script.js",
+			},
+			OutputConfig: loadtestingapi.TestOutputConfig{
+				InfluxURL:   "https://influxdb.example.com",
+				InfluxToken: "influx-secret-token",
+			},
+		}
+		obj = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: job.Name, Namespace: job.GetNamespace()}}
+		Expect(k8s.Create(ctx, obj)).To(Succeed())
+	})
+
+	It("never journals the output config or the env vars", func() {
+		Expect(reconciler.journalJob(ctx, job)).To(Succeed())
+
+		Expect(k8s.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+		Expect(obj.Annotations[annotationJournal]).NotTo(BeEmpty())
+		Expect(obj.Annotations[annotationJournal]).NotTo(ContainSubstring("influx-secret-token"))
+		Expect(obj.Annotations[annotationJournal]).NotTo(ContainSubstring("influxdb.example.com"))
+		Expect(obj.Annotations[annotationJournal]).NotTo(ContainSubstring("env-secret"))
+	})
+
+	It("keeps what's needed to reconcile offline", func() {
+		Expect(reconciler.journalJob(ctx, job)).To(Succeed())
+
+		journaled, found := reconciler.readJournal(ctx, client.ObjectKeyFromObject(obj))
+		Expect(found).To(BeTrue())
+		Expect(journaled.Name).To(Equal(job.Name))
+		Expect(journaled.Status).To(Equal(job.Status))
+		Expect(journaled.StatusDescription).To(Equal(job.StatusDescription))
+		Expect(journaled.AssignedSegments).To(Equal(job.AssignedSegments))
+		Expect(journaled.IsConditionTrue(loadtestingapi.CONDITION_PODS_READY)).To(BeTrue())
+		Expect(journaled.TestRun.Ready).To(BeTrue())
+		Expect(journaled.StartAt()).To(BeTemporally("==", job.StartAt()))
+		Expect(journaled.LocationName).To(Equal(reconciler.Location))
+	})
+
+	It("keeps the namespace of the job in per-team namespace mode", func() {
+		defer func(mode string) { options.NamespaceMode = mode }(options.NamespaceMode)
+		options.NamespaceMode = options.NamespaceModePerTeam
+		job.TestRun.Labels = map[string]string{options.NamespaceTeamLabel: "checkout"}
+
+		Expect(newJournal(job).job().GetNamespace()).To(Equal(job.GetNamespace()))
+	})
+})
@@ -0,0 +1,23 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6"
+	k6api "github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/api"
+)
+
+//+kubebuilder:rbac:groups=core,resources=pods/proxy,verbs=get;post;put;patch;delete
+
+// getK6Status retrieves the status of the k6 instance running in a pod, trough the pod dialer.
+func (r *TestRunReconciler) getK6Status(ctx context.Context, namespace string, podName string) (*k6api.StatusAttributes, error) {
+	return k6.NewClient(r.PodDialer).Status(ctx, namespace, podName)
+}
+
+// patchK6Status updates the status of the k6 instance running in a pod, trough the pod dialer.
+func (r *TestRunReconciler) patchK6Status(ctx context.Context, namespace string, podName string, attributes k6api.StatusAttributes) (*k6api.StatusAttributes, error) {
+	return k6.NewClient(r.PodDialer).PatchStatus(ctx, namespace, podName, attributes)
+}
@@ -0,0 +1,96 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Causes of worker pod failures.
+const (
+	// failureCauseDisruption is a worker pod evicted by a node disruption (drain, preemption, ...).
+	failureCauseDisruption = "disruption"
+	// failureCauseScript is a worker pod which failed on its own, usually because of the test script.
+	failureCauseScript = "script"
+)
+
+var workerPodFailures = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "orderly_ape_worker_pod_failures_total",
+		Help: "Number of failed worker pods, by location and cause (disruption or script).",
+	},
+	[]string{"location", "cause"},
+)
+
+var orphansDeleted = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "orderly_ape_orphans_deleted_total",
+		Help: "Number of resources of deleted or canceled test runs removed by the orphan sweeper, by location and kind.",
+	},
+	[]string{"location", "kind"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(workerPodFailures, orphansDeleted)
+}
+
+// PodFailures counts every failed worker pod once, as the same pods are seen on every reconcile. It's safe for
+// concurrent use.
+type PodFailures struct {
+	mu   sync.Mutex
+	seen map[string]map[types.UID]struct{}
+}
+
+// NewPodFailures instantiates an empty failure counter.
+func NewPodFailures() *PodFailures {
+	return &PodFailures{seen: make(map[string]map[types.UID]struct{})}
+}
+
+// Observe counts the failed pods of a job which weren't counted yet. Disrupted pods are counted as soon as they're
+// being evicted.
+func (f *PodFailures) Observe(location string, name string, pods []corev1.Pod) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, pod := range pods {
+		cause := ""
+		switch {
+		case isPodDisrupted(&pod):
+			cause = failureCauseDisruption
+		case isPodFailed(&pod):
+			cause = failureCauseScript
+		default:
+			continue
+		}
+
+		if f.seen[name] == nil {
+			f.seen[name] = make(map[types.UID]struct{})
+		}
+		if _, found := f.seen[name][pod.UID]; found {
+			continue
+		}
+		f.seen[name][pod.UID] = struct{}{}
+		workerPodFailures.WithLabelValues(location, cause).Inc()
+	}
+}
+
+// Forget drops the pods seen for a job, once it's done.
+func (f *PodFailures) Forget(name string) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.seen, name)
+}
@@ -0,0 +1,220 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+// namespacePolicyName is the name of the policy objects created in every managed namespace.
+const namespacePolicyName = "orderly-ape"
+
+// namespaceCleanupDelay leaves the TTL controller time to delete an expired Job before its namespace is cleaned up.
+const namespaceCleanupDelay = 10 * time.Second
+
+// NamespacePolicy describes the policies applied to the namespaces created by the operator.
+type NamespacePolicy struct {
+	ResourceQuota *corev1.ResourceQuotaSpec       `json:"resourceQuota,omitempty"`
+	LimitRange    *corev1.LimitRangeSpec          `json:"limitRange,omitempty"`
+	NetworkPolicy *networkingv1.NetworkPolicySpec `json:"networkPolicy,omitempty"`
+	// RoleBinding grants permissions in the managed namespaces only, ex. the access of the operator to the secrets
+	// of the jobs.
+	RoleBinding *NamespaceRoleBinding `json:"roleBinding,omitempty"`
+}
+
+// NamespaceRoleBinding is the role binding created in every managed namespace.
+type NamespaceRoleBinding struct {
+	RoleRef  rbacv1.RoleRef   `json:"roleRef"`
+	Subjects []rbacv1.Subject `json:"subjects"`
+}
+
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=core,resources=limitranges,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind
+
+// isLocationNamespace returns true for the shared jobs namespace and the namespaces configured for the locations,
+// which the operator uses but doesn't manage.
+func isLocationNamespace(name string) bool {
+	if name == options.JobNamespace {
+		return true
+	}
+	for _, namespace := range options.LocationNamespaces {
+		if name == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// syncNamespace makes sure the namespace of a job exists and has the configured policies applied.
+// It's a noop for the namespaces of the locations.
+func (r *TestRunReconciler) syncNamespace(ctx context.Context, job *loadtestingapi.Job) error {
+	name := job.GetNamespace()
+	if isLocationNamespace(name) {
+		return nil
+	}
+
+	ns := &corev1.Namespace{
+		ObjectMeta: ctrl.ObjectMeta{
+			Name: name,
+		},
+	}
+	_, err := ctrl.CreateOrUpdate(ctx, r.Client, ns, func() error {
+		if len(ns.Labels) == 0 {
+			ns.Labels = make(map[string]string)
+		}
+		ns.Labels["app.kubernetes.io/managed-by"] = "orderly-ape"
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if r.NamespacePolicy == nil {
+		return nil
+	}
+
+	if spec := r.NamespacePolicy.ResourceQuota; spec != nil {
+		obj := &corev1.ResourceQuota{ObjectMeta: namespacePolicyMeta(name)}
+		_, err = ctrl.CreateOrUpdate(ctx, r.Client, obj, func() error {
+			obj.Spec = *spec.DeepCopy()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if spec := r.NamespacePolicy.LimitRange; spec != nil {
+		obj := &corev1.LimitRange{ObjectMeta: namespacePolicyMeta(name)}
+		_, err = ctrl.CreateOrUpdate(ctx, r.Client, obj, func() error {
+			obj.Spec = *spec.DeepCopy()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if spec := r.NamespacePolicy.NetworkPolicy; spec != nil {
+		obj := &networkingv1.NetworkPolicy{ObjectMeta: namespacePolicyMeta(name)}
+		_, err = ctrl.CreateOrUpdate(ctx, r.Client, obj, func() error {
+			obj.Spec = *spec.DeepCopy()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if spec := r.NamespacePolicy.RoleBinding; spec != nil {
+		obj := &rbacv1.RoleBinding{ObjectMeta: namespacePolicyMeta(name)}
+		_, err = ctrl.CreateOrUpdate(ctx, r.Client, obj, func() error {
+			// the role of a binding can't be changed, but the binding is deleted with its namespace anyway
+			obj.RoleRef = spec.RoleRef
+			obj.Subjects = append([]rbacv1.Subject(nil), spec.Subjects...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanupFinishedJob removes the namespace of a completed or failed job once its Kubernetes Job was garbage
+// collected, after its TTL. The Job is kept until then, so its pods and logs can be inspected.
+func (r *TestRunReconciler) cleanupFinishedJob(ctx context.Context, key types.NamespacedName) (ctrl.Result, error) {
+	obj := &batchv1.Job{}
+	err := r.Get(ctx, key, obj)
+	if apierrors.IsNotFound(err) {
+		return ctrl.Result{}, r.cleanupNamespace(ctx, key.Namespace)
+	}
+	if err != nil || options.NamespaceMode == options.NamespaceModeShared {
+		return ctrl.Result{}, err
+	}
+
+	// the deletion of the Job reconciles it again, but it can be missed while the operator is down
+	return ctrl.Result{RequeueAfter: expiresIn(obj)}, nil
+}
+
+// expiresIn returns how long until a Job is garbage collected after its TTL, or the whole TTL if it isn't
+// finished yet.
+func expiresIn(obj *batchv1.Job) time.Duration {
+	ttl := time.Hour
+	if obj.Spec.TTLSecondsAfterFinished != nil {
+		ttl = time.Duration(*obj.Spec.TTLSecondsAfterFinished) * time.Second
+	}
+
+	for _, condition := range obj.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
+			condition.Status == corev1.ConditionTrue {
+			return max(time.Until(condition.LastTransitionTime.Add(ttl)), 0) + namespaceCleanupDelay
+		}
+	}
+	return ttl
+}
+
+// cleanupNamespace removes a namespace created by the operator, once all the jobs in it were garbage collected.
+// The namespaces of the locations are never removed.
+func (r *TestRunReconciler) cleanupNamespace(ctx context.Context, name string) error {
+	if name == "" || isLocationNamespace(name) {
+		return nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, ns); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if ns.Labels["app.kubernetes.io/managed-by"] != "orderly-ape" || ns.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	jobs := &batchv1.JobList{}
+	err := r.List(ctx, jobs, client.InNamespace(name), client.MatchingLabels{
+		"app.kubernetes.io/managed-by": "orderly-ape",
+	})
+	if err != nil {
+		return err
+	}
+	if len(jobs.Items) > 0 {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Deleting unused namespace", "namespace", name)
+	err = r.Delete(ctx, ns)
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
+func namespacePolicyMeta(namespace string) ctrl.ObjectMeta {
+	return ctrl.ObjectMeta{
+		Name:      namespacePolicyName,
+		Namespace: namespace,
+		Labels: map[string]string{
+			"app.kubernetes.io/managed-by": "orderly-ape",
+		},
+	}
+}
@@ -0,0 +1,152 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/fake"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+var _ = Describe("Namespace cleanup", func() {
+	const location = "namespace-test"
+
+	var (
+		ctx        context.Context
+		k8s        client.Client
+		reconciler *TestRunReconciler
+		job        *loadtestingapi.Job
+		ns         *corev1.Namespace
+		obj        *batchv1.Job
+		req        ctrl.Request
+	)
+
+	BeforeEach(func() {
+		DeferCleanup(func(mode string) { options.NamespaceMode = mode }, options.NamespaceMode)
+		options.NamespaceMode = options.NamespaceModePerTestRun
+
+		ctx = context.Background()
+		k8s = clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+		reconciler = &TestRunReconciler{
+			Client:    k8s,
+			Location:  location,
+			APIClient: fake.NewAPI().Client(location),
+			igniters:  NewIgniters(),
+			failures:  NewPodFailures(),
+		}
+
+		job = &loadtestingapi.Job{Name: "finished", LocationName: location, Status: loadtestingapi.STATUS_COMPLETED}
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   job.GetNamespace(),
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "orderly-ape"},
+		}}
+		Expect(k8s.Create(ctx, ns)).To(Succeed())
+
+		ttl := int32(3600)
+		obj = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      job.Name,
+				Namespace: ns.Name,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "orderly-ape"},
+			},
+			Spec: batchv1.JobSpec{TTLSecondsAfterFinished: &ttl},
+			Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{{
+				Type:               batchv1.JobComplete,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Minute)),
+			}}},
+		}
+		Expect(k8s.Create(ctx, obj)).To(Succeed())
+		req = ctrl.Request{NamespacedName: types.NamespacedName{Name: obj.Name, Namespace: obj.Namespace}}
+	})
+
+	namespaceExists := func() bool {
+		err := k8s.Get(ctx, client.ObjectKeyFromObject(ns), &corev1.Namespace{})
+		Expect(client.IgnoreNotFound(err)).To(Succeed())
+		return !apierrors.IsNotFound(err)
+	}
+
+	It("keeps the namespace of a finished job until its Kubernetes Job expires", func() {
+		result, err := reconciler.reconcileJob(ctx, req, job, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaceExists()).To(BeTrue())
+		Expect(result.RequeueAfter).To(BeNumerically("~", 59*time.Minute+namespaceCleanupDelay, time.Second))
+
+		Expect(k8s.Delete(ctx, obj)).To(Succeed())
+		result, err = reconciler.reconcileJob(ctx, req, job, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(namespaceExists()).To(BeFalse())
+	})
+
+	It("keeps the namespace while other jobs are in it", func() {
+		Expect(k8s.Delete(ctx, obj)).To(Succeed())
+		other := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name:      "other",
+			Namespace: ns.Name,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "orderly-ape"},
+		}}
+		Expect(k8s.Create(ctx, other)).To(Succeed())
+
+		_, err := reconciler.reconcileJob(ctx, req, job, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaceExists()).To(BeTrue())
+	})
+
+	It("cleans up the namespace of a job removed from the API once its Kubernetes Job is gone", func() {
+		Expect(k8s.Delete(ctx, obj)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaceExists()).To(BeFalse())
+	})
+
+	It("never manages nor removes the namespaces of the locations", func() {
+		DeferCleanup(func(namespaces map[string]string) { options.LocationNamespaces = namespaces }, options.LocationNamespaces)
+		options.NamespaceMode = options.NamespaceModePerTeam
+		options.LocationNamespaces = map[string]string{location: "loadtesting"}
+		reconciler.NamespacePolicy = &NamespacePolicy{LimitRange: &corev1.LimitRangeSpec{}}
+
+		Expect(reconciler.syncNamespace(ctx, &loadtestingapi.Job{Name: "teamless", LocationName: location})).To(Succeed())
+		Expect(apierrors.IsNotFound(k8s.Get(ctx, client.ObjectKey{Name: "loadtesting"}, &corev1.Namespace{}))).To(BeTrue())
+
+		// even if it looks like one created by the operator
+		locationNs := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   "loadtesting",
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "orderly-ape"},
+		}}
+		Expect(k8s.Create(ctx, locationNs)).To(Succeed())
+		Expect(reconciler.cleanupNamespace(ctx, locationNs.Name)).To(Succeed())
+		Expect(k8s.Get(ctx, client.ObjectKeyFromObject(locationNs), locationNs)).To(Succeed())
+	})
+
+	It("binds the role of the policy in the namespaces it creates", func() {
+		reconciler.NamespacePolicy = &NamespacePolicy{RoleBinding: &NamespaceRoleBinding{
+			RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "orderly-ape-secrets"},
+			Subjects: []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "orderly-ape", Namespace: "operator"}},
+		}}
+
+		Expect(reconciler.syncNamespace(ctx, job)).To(Succeed())
+		binding := &rbacv1.RoleBinding{}
+		Expect(k8s.Get(ctx, client.ObjectKey{Name: namespacePolicyName, Namespace: ns.Name}, binding)).To(Succeed())
+		Expect(binding.RoleRef.Name).To(Equal("orderly-ape-secrets"))
+		Expect(binding.Subjects).To(HaveLen(1))
+	})
+})
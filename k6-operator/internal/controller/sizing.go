@@ -0,0 +1,305 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6"
+	k6api "github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/api"
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+const (
+	// sizingPollInterval is how often the inspection, and the sizing of the other locations, are checked.
+	sizingPollInterval = 5 * time.Second
+	// inspectDeadline is the time allowed for the script to be fetched and inspected.
+	inspectDeadline int64 = 300
+	// inspectComponent labels the Kubernetes Jobs inspecting the test scripts, so they aren't reconciled as test
+	// runs.
+	inspectComponent = "inspect"
+
+	mebibyte int64 = 1 << 20
+)
+
+// Defaults of the sizing profile.
+var (
+	defaultVUsPerCPU   int64 = 200
+	defaultMemoryPerVU       = resource.MustParse("4Mi")
+	defaultBaseMemory        = resource.MustParse("256Mi")
+)
+
+// sizeJob sizes the worker pods of a job from the execution requirements of its script, inspected by a preflight
+// Kubernetes Job. With automatic sizing, it waits for all the locations to be sized, as their worker counts
+// decide the segments of the test run. It returns how long to wait before checking again, zero once the worker
+// pods can be created.
+func (r *TestRunReconciler) sizeJob(ctx context.Context, job *loadtestingapi.Job) (time.Duration, error) {
+	mode := job.TestRun.GetSizing()
+
+	if job.Sizing == nil {
+		reqs, done, err := r.inspectScript(ctx, job)
+		if !done && err != nil {
+			return 0, err
+		}
+		if !done {
+			changed := job.SetCondition(loadtestingapi.CONDITION_SIZED, loadtestingapi.CONDITION_FALSE, "Inspecting",
+				"Inspecting the execution requirements of the script")
+			return sizingPollInterval, r.reportConditions(ctx, job, changed)
+		}
+		if err != nil {
+			job.SetCondition(loadtestingapi.CONDITION_SIZED, loadtestingapi.CONDITION_FALSE, "InspectionFailed", err.Error())
+			job.Status = loadtestingapi.STATUS_FAILED
+			job.StatusDescription = fmt.Sprintf("Failed inspecting the test script: %s", err)
+			return 0, r.reportJobStatus(ctx, job)
+		}
+
+		resources := r.workerResources(job)
+		job.Sizing = computeSizing(reqs, job, r.SizingProfile, *resources.Cpu(), mode == loadtestingapi.SIZING_AUTO)
+		if mode == loadtestingapi.SIZING_RECOMMEND {
+			job.SetCondition(loadtestingapi.CONDITION_SIZED, loadtestingapi.CONDITION_TRUE, "Recommended", describeSizing(job.Sizing))
+		}
+		if err := r.updateJobStatus(ctx, job); err != nil {
+			return 0, err
+		}
+	}
+
+	if mode == loadtestingapi.SIZING_AUTO && !job.TestRun.Sized {
+		changed := job.SetCondition(loadtestingapi.CONDITION_SIZED, loadtestingapi.CONDITION_FALSE, "WaitingForLocations",
+			"Waiting for all the locations to be sized")
+		return sizingPollInterval, r.reportConditions(ctx, job, changed)
+	}
+
+	if mode == loadtestingapi.SIZING_AUTO {
+		// the condition is sent along with the next status change
+		job.SetCondition(loadtestingapi.CONDITION_SIZED, loadtestingapi.CONDITION_TRUE, "Applied", describeSizing(job.Sizing))
+	}
+	return 0, nil
+}
+
+//+kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+
+// inspectScript runs `k6 inspect` on the script of a job, in a Kubernetes Job, and returns its execution
+// requirements. done is false while the inspection is running. The Kubernetes Job is removed once it's done, so
+// retrying the test run inspects the script again.
+func (r *TestRunReconciler) inspectScript(ctx context.Context, job *loadtestingapi.Job) (*k6api.ExecutionRequirements, bool, error) {
+	obj := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: job.GetNamespace(), Name: inspectJobName(job)}, obj)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, false, err
+	}
+	if err != nil {
+		log.FromContext(ctx).Info("Inspecting the test script")
+		return nil, false, client.IgnoreAlreadyExists(r.Create(ctx, r.inspectJob(job)))
+	}
+
+	failed := getJobCondition(obj, batchv1.JobFailed)
+	if failed == nil && getJobCondition(obj, batchv1.JobComplete) == nil {
+		return nil, false, nil
+	}
+
+	logs, err := r.inspectLogs(ctx, obj)
+	if err != nil && failed == nil {
+		// the logs should be available soon
+		return nil, false, err
+	}
+
+	defer func() {
+		propagation := metav1.DeletePropagationBackground
+		if err := r.Delete(ctx, obj, &client.DeleteOptions{PropagationPolicy: &propagation}); client.IgnoreNotFound(err) != nil {
+			log.FromContext(ctx).Error(err, "Failed removing the script inspection job")
+		}
+	}()
+
+	if failed != nil {
+		if line := lastLine(logs); line != "" {
+			return nil, true, fmt.Errorf("%s", line)
+		}
+		return nil, true, fmt.Errorf("%s", failed.Message)
+	}
+
+	reqs, err := k6.ParseExecutionRequirements(logs)
+	return reqs, true, err
+}
+
+// inspectLogs returns the logs of the k6 container of the pod inspecting the script.
+func (r *TestRunReconciler) inspectLogs(ctx context.Context, obj *batchv1.Job) ([]byte, error) {
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods, client.InNamespace(obj.Namespace), client.MatchingLabels{
+		"batch.kubernetes.io/job-name": obj.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pod found for %s", obj.Name)
+	}
+
+	return r.PodLogs.Logs(ctx, obj.Namespace, pods.Items[0].Name, "k6")
+}
+
+// inspectJob returns the Kubernetes Job fetching the script of a job and printing its execution requirements.
+func (r *TestRunReconciler) inspectJob(job *loadtestingapi.Job) *batchv1.Job {
+	ttlSecondsAfterFinished := int32(600)
+	deadline := inspectDeadline
+
+	pullPolicy := corev1.PullIfNotPresent
+	if strings.HasSuffix(K6Image, ":latest") {
+		pullPolicy = corev1.PullAlways
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: ctrl.ObjectMeta{
+			Name:      inspectJobName(job),
+			Namespace: job.GetNamespace(),
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "k6",
+				"app.kubernetes.io/instance":   job.GetName(),
+				"app.kubernetes.io/component":  inspectComponent,
+				"app.kubernetes.io/managed-by": "orderly-ape",
+				labelLocation:                  r.Location,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			TTLSecondsAfterFinished: &ttlSecondsAfterFinished,
+			ActiveDeadlineSeconds:   &deadline,
+			BackoffLimit:            &zero32,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					SecurityContext: &corev1.PodSecurityContext{
+						FSGroup:    &groupID,
+						RunAsUser:  &userID,
+						RunAsGroup: &groupID,
+					},
+					NodeSelector:   r.nodeSelector(job),
+					Tolerations:    r.Tolerations,
+					InitContainers: []corev1.Container{gitContainer(job)},
+					Containers: []corev1.Container{{
+						Name:            "k6",
+						Image:           K6Image,
+						ImagePullPolicy: pullPolicy,
+						WorkingDir:      "/scripts",
+						Command:         k6.InspectCommand(job.TestRun.SourceScript),
+						Env:             scriptEnv(job),
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "k6-script",
+							MountPath: "/scripts",
+						}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "k6-script",
+						VolumeSource: corev1.VolumeSource{
+							EmptyDir: &corev1.EmptyDirVolumeSource{},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func inspectJobName(job *loadtestingapi.Job) string {
+	return job.GetName() + "-inspect"
+}
+
+// computeSizing decides the worker count and resources of a location, so that each worker runs no more VUs than
+// its CPU allows. The location runs its share of the VUs of the whole test run, given by its assigned segments.
+func computeSizing(reqs *k6api.ExecutionRequirements, job *loadtestingapi.Job, profile options.SizingProfile, cpu resource.Quantity, apply bool) *loadtestingapi.Sizing {
+	vusPerCPU := profile.VUsPerCPU
+	if vusPerCPU <= 0 {
+		vusPerCPU = defaultVUsPerCPU
+	}
+	memoryPerVU := defaultMemoryPerVU
+	if profile.MemoryPerVU != nil {
+		memoryPerVU = *profile.MemoryPerVU
+	}
+	baseMemory := defaultBaseMemory
+	if profile.BaseMemory != nil {
+		baseMemory = *profile.BaseMemory
+	}
+	if cpu.IsZero() {
+		cpu = resource.MustParse("1")
+	}
+
+	share, _ := segmentsShare(job.AssignedSegments).Float64()
+	vus := int64(math.Ceil(float64(reqs.MaxVUs) * share))
+
+	capacity := max(1, int64(float64(vusPerCPU)*float64(cpu.MilliValue())/1000))
+	workers := int32(max(1, (vus+capacity-1)/capacity))
+	if profile.MaxWorkers > 0 && workers > profile.MaxWorkers {
+		workers = profile.MaxWorkers
+	}
+
+	// round up to the next MiB, for readability
+	vusPerWorker := (vus + int64(workers) - 1) / int64(workers)
+	bytes := baseMemory.Value() + memoryPerVU.Value()*vusPerWorker
+	memory := resource.NewQuantity((bytes+mebibyte-1)/mebibyte*mebibyte, resource.BinarySI)
+
+	sizing := &loadtestingapi.Sizing{
+		MaxVUs:  reqs.MaxVUs,
+		VUs:     vus,
+		Workers: workers,
+		CPU:     cpu,
+		Memory:  *memory,
+		Applied: apply,
+	}
+	if duration, err := time.ParseDuration(reqs.TotalDuration); err == nil {
+		sizing.TotalDuration.Duration = duration
+	}
+	if len(reqs.Scenarios) > 0 {
+		sizing.Scenarios = make(map[string]string, len(reqs.Scenarios))
+		for name, scenario := range reqs.Scenarios {
+			sizing.Scenarios[name] = scenario.Executor
+		}
+	}
+
+	return sizing
+}
+
+// segmentsShare returns the fraction of the test run covered by segments, like `0:1/3`.
+func segmentsShare(segments []loadtestingapi.Segment) *big.Rat {
+	share := new(big.Rat)
+	for _, segment := range segments {
+		from, to, found := strings.Cut(segment.Segment, ":")
+		if !found {
+			continue
+		}
+		start, ok := new(big.Rat).SetString(from)
+		if !ok {
+			continue
+		}
+		end, ok := new(big.Rat).SetString(to)
+		if !ok {
+			continue
+		}
+		share.Add(share, end.Sub(end, start))
+	}
+	return share
+}
+
+func describeSizing(sizing *loadtestingapi.Sizing) string {
+	cpu, memory := sizing.CPU, sizing.Memory
+	return fmt.Sprintf("%d VUs out of %d on %d workers, with %s CPU and %s memory each",
+		sizing.VUs, sizing.MaxVUs, sizing.Workers, cpu.String(), memory.String())
+}
+
+// lastLine returns the last non empty line of the output of a command.
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,78 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	k6api "github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/api"
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+var _ = Describe("Sizing", func() {
+	var (
+		reqs *k6api.ExecutionRequirements
+		job  *loadtestingapi.Job
+	)
+
+	BeforeEach(func() {
+		reqs = &k6api.ExecutionRequirements{
+			MaxVUs:        900,
+			TotalDuration: "5m30s",
+			Scenarios:     map[string]k6api.Scenario{"browse": {Executor: "ramping-vus"}},
+		}
+		// two workers out of three
+		job = &loadtestingapi.Job{
+			Name: "test-run",
+			AssignedSegments: []loadtestingapi.Segment{
+				{ID: "1", Segment: "0:1/3"},
+				{ID: "2", Segment: "1/3:2/3"},
+			},
+		}
+	})
+
+	It("runs the share of the location within the capacity of each worker", func() {
+		sizing := computeSizing(reqs, job, options.SizingProfile{}, resource.MustParse("1"), true)
+
+		Expect(sizing.MaxVUs).To(Equal(int64(900)))
+		Expect(sizing.VUs).To(Equal(int64(600)))
+		Expect(sizing.Workers).To(Equal(int32(3)))
+		Expect(sizing.TotalDuration.Duration).To(Equal(5*time.Minute + 30*time.Second))
+		Expect(sizing.Scenarios).To(Equal(map[string]string{"browse": "ramping-vus"}))
+		Expect(sizing.Applied).To(BeTrue())
+		// 256Mi + 200 VUs * 4Mi
+		Expect(sizing.Memory.Cmp(resource.MustParse("1056Mi"))).To(BeZero())
+	})
+
+	It("follows the sizing profile of the location", func() {
+		memoryPerVU := resource.MustParse("1Mi")
+		profile := options.SizingProfile{VUsPerCPU: 100, MemoryPerVU: &memoryPerVU, MaxWorkers: 2}
+
+		sizing := computeSizing(reqs, job, profile, resource.MustParse("500m"), false)
+
+		Expect(sizing.Workers).To(Equal(int32(2)))
+		Expect(sizing.Memory.Cmp(resource.MustParse("556Mi"))).To(BeZero())
+		Expect(sizing.Applied).To(BeFalse())
+	})
+
+	It("keeps at least one worker", func() {
+		reqs.MaxVUs = 1
+
+		sizing := computeSizing(reqs, job, options.SizingProfile{}, resource.MustParse("2"), true)
+
+		Expect(sizing.VUs).To(Equal(int64(1)))
+		Expect(sizing.Workers).To(Equal(int32(1)))
+	})
+
+	It("computes the share of the assigned segments", func() {
+		share := segmentsShare([]loadtestingapi.Segment{{Segment: "1/4:1/2"}, {Segment: "3/4:1"}})
+
+		Expect(share.RatString()).To(Equal("1/2"))
+	})
+})
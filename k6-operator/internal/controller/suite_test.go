@@ -5,6 +5,7 @@ package controller
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -38,7 +39,7 @@ var _ = BeforeSuite(func() {
 	logf.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
 
 	By("bootstrapping test environment")
-	testEnv = &envtest.Environment{
+	env := &envtest.Environment{
 		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
 		ErrorIfCRDPathMissing: true,
 
@@ -51,6 +52,12 @@ var _ = BeforeSuite(func() {
 			fmt.Sprintf("1.29.0-%s-%s", runtime.GOOS, runtime.GOARCH)),
 	}
 
+	// most specs don't need a Kubernetes API, they still run when its binaries aren't installed
+	if !envtestAssetsInstalled(env.BinaryAssetsDirectory) {
+		return
+	}
+	testEnv = env
+
 	var err error
 	// cfg is defined in this file globally.
 	cfg, err = testEnv.Start()
@@ -66,7 +73,31 @@ var _ = BeforeSuite(func() {
 })
 
 var _ = AfterSuite(func() {
+	if testEnv == nil {
+		return
+	}
+
 	By("tearing down the test environment")
 	err := testEnv.Stop()
 	Expect(err).NotTo(HaveOccurred())
 })
+
+// envtestAssetsInstalled returns true if the binaries of the test environment are found where envtest looks for them.
+func envtestAssetsInstalled(dir string) bool {
+	for _, path := range []string{dir, os.Getenv("KUBEBUILDER_ASSETS"), "/usr/local/kubebuilder/bin"} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(path, "kube-apiserver")); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// requireEnvtest skips the current spec if it needs the test environment, and its binaries aren't installed.
+func requireEnvtest() {
+	if testEnv == nil {
+		Skip("envtest binaries are not installed, run make test")
+	}
+}
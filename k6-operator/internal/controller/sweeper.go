@@ -0,0 +1,251 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting"
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+const (
+	// labelLocation is the location which created a resource, so the sweeper of a location leaves the resources of
+	// the other ones alone.
+	labelLocation = "orderly-ape.reviewsignal.com/location"
+	// annotationOrphanedAt is when the sweeper first found a resource orphaned, in RFC 3339 format.
+	annotationOrphanedAt = "orderly-ape.reviewsignal.com/orphaned-at"
+)
+
+// OrphanSweeper deletes the Kubernetes resources (Jobs, telegraf secrets and PodDisruptionBudgets) of the test runs
+// removed from the API while the operator wasn't watching, and the suspended Jobs of canceled test runs, which never
+// finish and so are never removed by their TTL. Orphans are kept for Retention after they were first found, so a
+// test run briefly missing from the API isn't cleaned up. It sweeps at startup, then every Interval.
+type OrphanSweeper struct {
+	Reconciler *TestRunReconciler
+	// Reader lists the resources bypassing the cache, as the secrets aren't watched.
+	Reader    client.Reader
+	Retention time.Duration
+	Interval  time.Duration
+
+	// namespaces where resources were deleted, cleaned up on the next sweep once their jobs are gone
+	namespaces map[string]struct{}
+}
+
+// Start implements manager.Runnable.
+func (s *OrphanSweeper) Start(ctx context.Context) error {
+	l := ctrl.Log.WithName("orphan-sweeper").WithValues("location", s.Reconciler.Location)
+	ctx = ctrl.LoggerInto(ctx, l)
+
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+
+	for {
+		if err := s.Sweep(ctx); err != nil {
+			l.Error(err, "Failed sweeping orphaned resources")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// Sweep marks the orphaned resources, and deletes the ones orphaned for longer than the retention. Test runs the API
+// can't be asked about are left for the next sweep.
+func (s *OrphanSweeper) Sweep(ctx context.Context) error {
+	objs, err := s.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	instances := map[string][]client.Object{}
+	for _, obj := range objs {
+		if name := obj.GetLabels()["app.kubernetes.io/instance"]; name != "" {
+			instances[name] = append(instances[name], obj)
+		}
+	}
+
+	namespaces := s.namespaces
+	s.namespaces = map[string]struct{}{}
+
+	var errs []error
+	for name, objs := range instances {
+		orphaned, err := s.isOrphaned(ctx, name, objs)
+		if err != nil {
+			log.FromContext(ctx).Info("Unable to check test run in API, skipping it", "name", name, "error", err.Error())
+			continue
+		}
+
+		for _, obj := range objs {
+			deleted, err := s.sweep(ctx, obj, orphaned)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if deleted {
+				s.namespaces[obj.GetNamespace()] = struct{}{}
+			}
+		}
+	}
+
+	for namespace := range namespaces {
+		if err := s.Reconciler.cleanupNamespace(ctx, namespace); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// list returns the resources managed by the operator which belong to the location of the sweeper.
+func (s *OrphanSweeper) list(ctx context.Context) ([]client.Object, error) {
+	namespaces, err := s.listNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	objs := []client.Object{}
+	for _, namespace := range namespaces {
+		opts := []client.ListOption{
+			client.MatchingLabels{"app.kubernetes.io/managed-by": "orderly-ape"},
+			client.InNamespace(namespace),
+		}
+		for _, list := range []client.ObjectList{&batchv1.JobList{}, &corev1.SecretList{}, &policyv1.PodDisruptionBudgetList{}} {
+			if err := s.Reader.List(ctx, list, opts...); err != nil {
+				return nil, err
+			}
+			items, err := meta.ExtractList(list)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				obj, ok := item.(client.Object)
+				if ok && s.Reconciler.owns(obj) {
+					objs = append(objs, obj)
+				}
+			}
+		}
+	}
+	return objs, nil
+}
+
+// listNamespaces returns the namespaces the jobs of the location can be in: the namespace of the location and,
+// unless it's shared by all the jobs, the namespaces created by the operator. They are listed one by one, as the
+// operator can only read the secrets of those namespaces.
+func (s *OrphanSweeper) listNamespaces(ctx context.Context) ([]string, error) {
+	namespace := options.LocationNamespaces[s.Reconciler.Location]
+	if namespace == "" {
+		namespace = options.JobNamespace
+	}
+	namespaces := []string{namespace}
+	if options.NamespaceMode == options.NamespaceModeShared {
+		return namespaces, nil
+	}
+
+	list := &corev1.NamespaceList{}
+	if err := s.Reader.List(ctx, list, client.MatchingLabels{"app.kubernetes.io/managed-by": "orderly-ape"}); err != nil {
+		return nil, err
+	}
+	for _, ns := range list.Items {
+		if ns.Name != namespace {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	return namespaces, nil
+}
+
+// isOrphaned returns true if the resources of a test run can be deleted: the test run was removed from the API, or
+// it was canceled and its Jobs are suspended.
+func (s *OrphanSweeper) isOrphaned(ctx context.Context, name string, objs []client.Object) (bool, error) {
+	job := &loadtestingapi.Job{}
+	err := s.Reconciler.APIClient.Get(ctx, name, job)
+	if loadtesting.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if job.Status != loadtestingapi.STATUS_CANCELED {
+		return false, nil
+	}
+
+	for _, obj := range objs {
+		if parent, ok := obj.(*batchv1.Job); ok && (parent.Spec.Suspend == nil || !*parent.Spec.Suspend) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// sweep marks an orphaned resource, or deletes it once the retention is over. Resources which aren't orphaned
+// anymore are unmarked. It returns true if the resource was deleted.
+func (s *OrphanSweeper) sweep(ctx context.Context, obj client.Object, orphaned bool) (bool, error) {
+	value, marked := obj.GetAnnotations()[annotationOrphanedAt]
+	if !orphaned {
+		if !marked {
+			return false, nil
+		}
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		annotations := obj.GetAnnotations()
+		delete(annotations, annotationOrphanedAt)
+		obj.SetAnnotations(annotations)
+		return false, client.IgnoreNotFound(s.Reconciler.Patch(ctx, obj, patch))
+	}
+
+	if obj.GetDeletionTimestamp() != nil {
+		return false, nil
+	}
+
+	orphanedAt, err := time.Parse(time.RFC3339, value)
+	if !marked || err != nil {
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[annotationOrphanedAt] = time.Now().UTC().Format(time.RFC3339)
+		obj.SetAnnotations(annotations)
+		return false, client.IgnoreNotFound(s.Reconciler.Patch(ctx, obj, patch))
+	}
+
+	if time.Since(orphanedAt) < s.Retention {
+		return false, nil
+	}
+
+	log.FromContext(ctx).Info("Deleting orphaned resource", "kind", kindOf(obj), "name", obj.GetName(),
+		"namespace", obj.GetNamespace(), "orphanedAt", value)
+	bgDelete := metav1.DeletePropagationBackground
+	err = s.Reconciler.Delete(ctx, obj, &client.DeleteOptions{PropagationPolicy: &bgDelete})
+	if err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	orphansDeleted.WithLabelValues(s.Reconciler.Location, kindOf(obj)).Inc()
+	return true, nil
+}
+
+// kindOf returns the kind of the resources listed by the sweeper, as the listed items have no type meta.
+func kindOf(obj client.Object) string {
+	switch obj.(type) {
+	case *batchv1.Job:
+		return "Job"
+	case *corev1.Secret:
+		return "Secret"
+	case *policyv1.PodDisruptionBudget:
+		return "PodDisruptionBudget"
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/fake"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+func sweeperLabels(name string, location string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/instance":   name,
+		"app.kubernetes.io/managed-by": "orderly-ape",
+		labelLocation:                  location,
+	}
+}
+
+var _ = Describe("Orphan sweeper", func() {
+	const location = "sweeper-test"
+	const namespace = "default"
+
+	var (
+		ctx     context.Context
+		api     *fake.API
+		k8s     client.Client
+		sweeper *OrphanSweeper
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		api = fake.NewAPI()
+		k8s = clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+		sweeper = &OrphanSweeper{
+			Reconciler: &TestRunReconciler{
+				Client:    k8s,
+				Location:  location,
+				APIClient: api.Client(location),
+			},
+			Reader:    k8s,
+			Retention: time.Minute,
+		}
+	})
+
+	addJob := func(name string, owner string, suspended bool) *batchv1.Job {
+		obj := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: sweeperLabels(name, owner)},
+			Spec:       batchv1.JobSpec{Suspend: &suspended},
+		}
+		Expect(k8s.Create(ctx, obj)).To(Succeed())
+		return obj
+	}
+
+	orphanedAt := func(obj client.Object) string {
+		Expect(k8s.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+		return obj.GetAnnotations()[annotationOrphanedAt]
+	}
+
+	backdate := func(obj client.Object) {
+		Expect(k8s.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+		annotations := obj.GetAnnotations()
+		annotations[annotationOrphanedAt] = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		obj.SetAnnotations(annotations)
+		Expect(k8s.Update(ctx, obj)).To(Succeed())
+	}
+
+	It("deletes the resources of test runs removed from the API once the retention is over", func() {
+		obj := addJob("removed", location, false)
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "removed-1", Namespace: namespace, Labels: sweeperLabels("removed", location)},
+		}
+		Expect(k8s.Create(ctx, secret)).To(Succeed())
+
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(orphanedAt(obj)).NotTo(BeEmpty())
+		Expect(orphanedAt(secret)).NotTo(BeEmpty())
+
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(k8s.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+
+		backdate(obj)
+		backdate(secret)
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(apierrors.IsNotFound(k8s.Get(ctx, client.ObjectKeyFromObject(obj), obj))).To(BeTrue())
+		Expect(apierrors.IsNotFound(k8s.Get(ctx, client.ObjectKeyFromObject(secret), secret))).To(BeTrue())
+	})
+
+	It("sweeps the suspended jobs of canceled test runs", func() {
+		Expect(api.AddRun(fake.Run{Name: "canceled", Locations: []fake.Location{{Name: location, Workers: 1}}})).To(Succeed())
+		Expect(api.Cancel("canceled", "Canceled by user")).To(Succeed())
+		obj := addJob("canceled", location, true)
+
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(orphanedAt(obj)).NotTo(BeEmpty())
+	})
+
+	It("keeps the resources of test runs still in the API", func() {
+		Expect(api.AddRun(fake.Run{Name: "active", Locations: []fake.Location{{Name: location, Workers: 1}}})).To(Succeed())
+		obj := addJob("active", location, true)
+		obj.Annotations = map[string]string{annotationOrphanedAt: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)}
+		Expect(k8s.Update(ctx, obj)).To(Succeed())
+
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(orphanedAt(obj)).To(BeEmpty())
+	})
+
+	It("sweeps the namespaces created by the operator, one by one", func() {
+		DeferCleanup(func(mode string) { options.NamespaceMode = mode }, options.NamespaceMode)
+		options.NamespaceMode = options.NamespaceModePerTestRun
+		managed := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   "orderly-ape-removed",
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "orderly-ape"},
+		}}
+		Expect(k8s.Create(ctx, managed)).To(Succeed())
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "removed-1", Namespace: managed.Name, Labels: sweeperLabels("removed", location)},
+		}
+		Expect(k8s.Create(ctx, secret)).To(Succeed())
+
+		Expect(sweeper.listNamespaces(ctx)).To(ConsistOf(options.JobNamespace, managed.Name))
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(orphanedAt(secret)).NotTo(BeEmpty())
+	})
+
+	It("leaves the resources of other locations alone", func() {
+		obj := addJob("elsewhere", "other-location", false)
+		unlabelled := addJob("unlabelled", location, false)
+		delete(unlabelled.Labels, labelLocation)
+		Expect(k8s.Update(ctx, unlabelled)).To(Succeed())
+
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(orphanedAt(obj)).To(BeEmpty())
+		Expect(orphanedAt(unlabelled)).To(BeEmpty())
+
+		sweeper.Reconciler.Exclusive = true
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(orphanedAt(obj)).To(BeEmpty())
+		Expect(orphanedAt(unlabelled)).NotTo(BeEmpty())
+	})
+})
+
+var _ = Describe("Locations sharing an operator", func() {
+	It("only reconcile the jobs labelled with their location", func() {
+		reconciler := &TestRunReconciler{Location: "east"}
+		job := func(labels map[string]string) *batchv1.Job {
+			return &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "default", Labels: labels}}
+		}
+
+		Expect(reconciler.owns(job(sweeperLabels("shared", "east")))).To(BeTrue())
+		Expect(reconciler.owns(job(sweeperLabels("shared", "west")))).To(BeFalse())
+		Expect(reconciler.owns(job(nil))).To(BeFalse())
+
+		reconciler.Exclusive = true
+		Expect(reconciler.owns(job(nil))).To(BeTrue())
+		Expect(reconciler.owns(job(sweeperLabels("shared", "west")))).To(BeFalse())
+	})
+})
@@ -5,6 +5,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -18,24 +19,29 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	clientset "k8s.io/client-go/kubernetes"
 	corev1util "kmodules.xyz/client-go/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6"
 	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting"
 	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
 	loadtestingruntime "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/runtime"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
 )
 
 const telegrafConfigVersion = 1
 
+// errJobChanged is returned when a status update is rejected because the job changed in the API.
+var errJobChanged = errors.New("job changed in the API")
+
 var (
 	zero32   int32 = 0
 	falsePtr *bool = func(b bool) *bool { return &b }(false)
@@ -66,14 +72,41 @@ type TestRunReconciler struct {
 	client.Client
 	Scheme    *runtime.Scheme
 	APIClient loadtesting.Client
-	Location  string
-	clientset clientset.Interface
-	igniters  Igniters
+	// StatusQueue, if set, queues the status updates failing because the API is unavailable and replays them.
+	StatusQueue *loadtesting.StatusQueue
+	Location    string
+	// MaxClockSkew is the maximum allowed offset between the local and the API clocks when igniting tests.
+	// Zero disables the check.
+	MaxClockSkew time.Duration
+	// MaxConcurrentReconciles is the maximum number of jobs reconciled in parallel. Defaults to 1.
+	MaxConcurrentReconciles int
+	// NamespacePolicy is applied to the namespaces created for test runs, when not using a shared namespace.
+	NamespacePolicy *NamespacePolicy
+	// Exclusive is set when this is the only location of the operator, so the Kubernetes Jobs created before they
+	// were labelled with their location are reconciled too.
+	Exclusive bool
+	// NotFoundConfirmations is how many times in a row the API needs to report a job as missing before its
+	// Kubernetes Job is deleted. Defaults to 3.
+	NotFoundConfirmations int
+	// NodeSelector and Tolerations are added to all the worker pods of this location.
+	NodeSelector map[string]string
+	Tolerations  []corev1.Toleration
+	// WorkerResources are the default resource requests of the worker pods.
+	WorkerResources corev1.ResourceList
+	// SizingProfile is the capacity of the worker pods, used when test runs are sized from their script.
+	SizingProfile options.SizingProfile
+	// PodDialer reaches the k6 REST API of the worker pods. Defaults to the pods/proxy subresource.
+	PodDialer k6.PodDialer
+	// PodLogs reads the logs of the pods inspecting the test scripts. Defaults to the pods/log subresource.
+	PodLogs  k6.PodLogReader
+	igniters *Igniters
+	failures *PodFailures
+	worker   *loadtesting.Worker
 }
 
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=core,resources=secrets,verbs=create
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;create;patch;delete
 //+kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=batch,resources=jobs/finalizers,verbs=update
 
@@ -89,35 +122,47 @@ type TestRunReconciler struct {
 func (r *TestRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	l := log.FromContext(ctx).WithValues("name", req.NamespacedName)
 
-	job := &loadtestingapi.Job{}
-	err := r.APIClient.Get(ctx, req.Name, job)
-	if loadtesting.IgnoreNotFound(err) != nil {
-		l.Error(err, "Failed retrieving Job from API", "job", job)
+	job, offline, err := r.getJob(ctx, req.NamespacedName)
+	if loadtesting.IsNotFound(err) {
+		return r.confirmJobRemoval(ctx, req.NamespacedName)
+	}
+	if err != nil {
+		l.Error(err, "Failed retrieving Job from API")
 		return ctrl.Result{}, err
 	}
 
-	if loadtesting.IsNotFound(err) {
-		bgDelete := metav1.DeletePropagationBackground
-		err = r.Delete(ctx, &batchv1.Job{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      req.Name,
-				Namespace: req.Namespace,
-			},
-		}, &client.DeleteOptions{
-			PropagationPolicy: &bgDelete,
-		})
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+	// Without the API, we keep the tests going using the last known state, until it's available again
+	if offline {
+		l.Info("API unavailable, using the last known job state", "status", job.Status)
 	}
-	l = l.WithValues("status", job.Status)
 
-	// If the job is completed or failed, we don't need to do anything
+	result, err := r.reconcileJob(ctx, req, job, offline)
+	if errors.Is(err, errJobChanged) {
+		// the status was decided on a stale job, so we need to decide again
+		l.Info("Job changed in the API while reconciling, reconciling again")
+		return ctrl.Result{Requeue: true}, nil
+	}
+	if offline && err == nil && result.IsZero() {
+		result.RequeueAfter = offlineRequeueInterval
+	}
+	return result, err
+}
+
+// reconcileJob moves the Kubernetes Job towards the state of the job. When offline, the job state comes from the
+// journal and status updates are queued until the API is available.
+func (r *TestRunReconciler) reconcileJob(ctx context.Context, req ctrl.Request, job *loadtestingapi.Job, offline bool) (ctrl.Result, error) {
+	l := log.FromContext(ctx).WithValues("name", req.NamespacedName, "status", job.Status)
+
+	// If the job is completed or failed, we don't need to do anything, besides cleaning up its namespace
+	// once the Kubernetes Job was garbage collected
 	if job.Status == loadtestingapi.STATUS_COMPLETED || job.Status == loadtestingapi.STATUS_FAILED {
 		r.removeIgniter(job)
-		return ctrl.Result{}, nil
+		r.failures.Forget(job.Name)
+		return r.cleanupFinishedJob(ctx, req.NamespacedName)
 	}
 
 	obj := &batchv1.Job{}
-	err = r.Get(ctx, req.NamespacedName, obj)
+	err := r.Get(ctx, req.NamespacedName, obj)
 	if client.IgnoreNotFound(err) != nil {
 		return ctrl.Result{}, err
 	}
@@ -126,6 +171,13 @@ func (r *TestRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, nil
 	}
 
+	if err == nil && !offline {
+		restoreConditions(obj, job)
+		if jerr := r.journalJob(ctx, job); jerr != nil {
+			l.Error(jerr, "Failed journaling job state")
+		}
+	}
+
 	// If the job is canceled, we need to suspend the job
 	if job.Status == loadtestingapi.STATUS_CANCELED {
 		// If the Job exists in kubernetes, we need to suspend it
@@ -135,6 +187,7 @@ func (r *TestRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			err = r.Update(ctx, obj)
 		}
 		r.removeIgniter(job)
+		r.failures.Forget(job.Name)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
@@ -144,23 +197,26 @@ func (r *TestRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if apierrors.IsNotFound(err) && job.Status != loadtestingapi.STATUS_PENDING {
 		job.Status = loadtestingapi.STATUS_FAILED
 		job.StatusDescription = fmt.Sprintf("Test was `%s` but no Kubernetes Job found", job.Status)
-		err = r.APIClient.Update(ctx, job)
-		if err != nil {
-			l.Error(err, "Failed updating job status", "job", job)
-		}
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, r.reportJobStatus(ctx, job)
 	}
 
 	if apierrors.IsNotFound(err) && job.Status == loadtestingapi.STATUS_PENDING {
+		if err = r.syncNamespace(ctx, job); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if job.TestRun.GetSizing() != loadtestingapi.SIZING_OFF {
+			requeueAfter, err := r.sizeJob(ctx, job)
+			if err != nil || requeueAfter > 0 || job.Status == loadtestingapi.STATUS_FAILED {
+				return ctrl.Result{RequeueAfter: requeueAfter}, err
+			}
+		}
+
 		obj, err = r.syncJob(ctx, job)
 		if err != nil {
 			job.Status = loadtestingapi.STATUS_FAILED
 			job.StatusDescription = fmt.Sprintf("Worker pods have failed running k6 tests: %s", err)
-			err = r.APIClient.Update(ctx, job)
-			if err != nil {
-				l.Error(err, "Failed updating job status", "job", job)
-			}
-			return ctrl.Result{}, err
+			return ctrl.Result{}, r.reportJobStatus(ctx, job)
 		}
 
 		_, err = r.syncTelegrafConfig(ctx, job, obj)
@@ -174,90 +230,180 @@ func (r *TestRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
-	if cond := getJobCondition(obj, batchv1.JobFailed); cond != nil {
+	// failed segments are tolerated only once the test is running
+	cond := getJobCondition(obj, batchv1.JobFailed)
+	if cond != nil && (job.Status != loadtestingapi.STATUS_RUNNING || !isToleratedFailure(cond)) {
+		if job.Status == loadtestingapi.STATUS_RUNNING {
+			job.LostSegments = lostSegments(job, obj)
+		}
 		job.Status = loadtestingapi.STATUS_FAILED
 		job.StatusDescription = fmt.Sprintf("Worker pods have failed running k6 tests: %s", cond.Message)
-		err = r.APIClient.Update(ctx, job)
-		if err != nil {
-			l.Error(err, "Failed updating job status", "job", job)
-		}
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, r.reportJobStatus(ctx, job)
+	}
+	if job.Status != loadtestingapi.STATUS_RUNNING && hasFailedIndexes(obj) {
+		job.Status = loadtestingapi.STATUS_FAILED
+		job.StatusDescription = fmt.Sprintf("Worker pods have failed before the test started (indexes %s)", *obj.Status.FailedIndexes)
+		return ctrl.Result{}, r.reportJobStatus(ctx, job)
 	}
 
 	if job.Status == loadtestingapi.STATUS_PENDING {
 		job.Status = loadtestingapi.STATUS_QUEUED
 		job.StatusDescription = "Test run is queued for execution"
-		err = r.APIClient.Update(ctx, job)
+		err = r.updateJobStatus(ctx, job)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
+	allPods, err := r.getPods(ctx, job)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.failures.Observe(r.Location, job.Name, allPods)
+	// pods evicted by a node disruption are replaced, only their replacements count
+	pods := withoutDisrupted(allPods)
+	// conditions changing without the status changing are reported at the end
+	conditionsChanged := updatePodConditions(job, pods)
+	conditionsChanged = updateDisruptions(job, allPods) || conditionsChanged
+	status := job.Status
+
 	if job.Status == loadtestingapi.STATUS_QUEUED {
 		if obj.Status.Ready != nil && int32(len(job.AssignedSegments)) == *obj.Status.Ready {
-			pods, err := r.getPods(ctx, job)
+			if !job.IsConditionTrue(loadtestingapi.CONDITION_PODS_READY) {
+				// pods need to be ready for a while, which doesn't trigger any event
+				return ctrl.Result{RequeueAfter: podStabilityPeriod}, r.reportConditions(ctx, job, conditionsChanged)
+			}
+			job.Status = loadtestingapi.STATUS_READY
+			job.StatusDescription = "Worker pods are ready and waiting to start testing"
+			err = r.updateJobStatus(ctx, job)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
-			ready := true
-			for _, pod := range pods {
-				if !isPodStableReady(&pod) {
-					ready = false
-				}
-			}
-			if ready {
-				job.Status = loadtestingapi.STATUS_READY
-				job.StatusDescription = "Worker pods are ready and waiting to start testing"
-				err = r.APIClient.Update(ctx, job)
-				if err != nil {
-					return ctrl.Result{}, err
-				}
+		}
+	}
+
+	if job.Status == loadtestingapi.STATUS_READY && hasDisruptedPods(allPods) {
+		// the igniter is created again once the replacement pods are ready
+		if r.releaseDisruptedIgniter(job, allPods) {
+			l.Info("Worker pod evicted before the test started, waiting for its replacement")
+		}
+		if _, found := r.igniters.Get(job.Name); !found {
+			conditionsChanged = updatePodsReady(job, pods) || conditionsChanged
+			if !job.IsConditionTrue(loadtestingapi.CONDITION_PODS_READY) {
+				return ctrl.Result{RequeueAfter: podStabilityPeriod}, r.reportConditions(ctx, job, conditionsChanged)
 			}
 		}
 	}
 
 	if job.Status == loadtestingapi.STATUS_READY {
-		igniter, err := r.createIgniter(ctx, job)
+		igniter, err := r.createIgniter(ctx, job, obj)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
 
-		if job.Status == loadtestingapi.STATUS_READY && igniter.Started && igniter.Error == nil {
+		state := igniter.State()
+		if !state.Done() {
+			conditionsChanged = job.SetCondition(loadtestingapi.CONDITION_IGNITED, loadtestingapi.CONDITION_FALSE, "WaitingForStart",
+				fmt.Sprintf("Pods will be started at %s", job.StartAt().UTC().Format(time.RFC3339))) || conditionsChanged
+		}
+
+		if job.Status == loadtestingapi.STATUS_READY && state.Started && state.Error == nil {
+			job.Ignition = igniter.Report(r.clockOffset())
+			job.SetCondition(loadtestingapi.CONDITION_IGNITED, loadtestingapi.CONDITION_TRUE, "Ignited",
+				fmt.Sprintf("All pods were started %s", describeIgnition(job.Ignition)))
 			job.Status = loadtestingapi.STATUS_RUNNING
-			job.StatusDescription = "Worker pods are currently running k6 tests"
-			err = r.APIClient.Update(ctx, job)
-			if err != nil {
-				l.Error(err, "Failed updating job status", "job", job)
+			job.StatusDescription = fmt.Sprintf("Worker pods are currently running k6 tests (started %s)", describeIgnition(job.Ignition))
+			if err = r.reportJobStatus(ctx, job); err != nil {
+				return ctrl.Result{}, err
 			}
 		}
 
-		if igniter.Error != nil {
+		if state.Error != nil {
+			job.Ignition = igniter.Report(r.clockOffset())
+			job.SetCondition(loadtestingapi.CONDITION_IGNITED, loadtestingapi.CONDITION_FALSE, "IgnitionFailed", state.Error.Error())
 			job.Status = loadtestingapi.STATUS_FAILED
-			job.StatusDescription = fmt.Sprintf("Worker pods have failed running k6 tests: %s", igniter.Error)
-			err = r.APIClient.Update(ctx, job)
-			if err != nil {
-				l.Error(err, "Failed updating job status", "job", job)
+			job.StatusDescription = fmt.Sprintf("Worker pods have failed running k6 tests: %s", state.Error)
+			if err = r.reportJobStatus(ctx, job); err != nil {
+				return ctrl.Result{}, err
 			}
 		}
 	}
 
+	var requeueAfter time.Duration
 	if job.Status == loadtestingapi.STATUS_RUNNING {
-		if obj.Status.Active == 0 {
-			if int(obj.Status.Succeeded) == len(job.AssignedSegments) {
-				job.Status = loadtestingapi.STATUS_COMPLETED
-				job.StatusDescription = "Worker pods have successfully completed running k6 tests"
-			} else {
-				job.StatusDescription = "Worker pods have failed running k6 tests"
-				job.Status = loadtestingapi.STATUS_FAILED
+		var changed bool
+		requeueAfter, changed = r.checkStartBarrier(ctx, job, pods)
+		conditionsChanged = changed || conditionsChanged
+		if job.Status == loadtestingapi.STATUS_FAILED {
+			r.removeIgniter(job)
+			return ctrl.Result{}, r.reportJobStatus(ctx, job)
+		}
+	}
+
+	if job.Status == loadtestingapi.STATUS_RUNNING {
+		// the replacements of the disrupted pods are never un-paused, so they're stopped once the others are done
+		replacements := disruptedReplacements(job, pods)
+		if obj.Status.Active == int32(len(replacements)) {
+			if err := r.stopPods(ctx, job.GetNamespace(), replacements); err != nil {
+				l.Error(err, "Failed stopping the replacements of the disrupted pods")
 			}
-			err = r.APIClient.Update(ctx, job)
+			completeJob(job, obj)
+			err = r.updateJobStatus(ctx, job)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 		}
 	}
 
-	return ctrl.Result{}, nil
+	// status changes already sent the conditions
+	return ctrl.Result{RequeueAfter: requeueAfter}, r.reportConditions(ctx, job, conditionsChanged && job.Status == status)
+}
+
+// reportConditions sends the job status when only its conditions changed.
+func (r *TestRunReconciler) reportConditions(ctx context.Context, job *loadtestingapi.Job, changed bool) error {
+	if !changed {
+		return nil
+	}
+	return r.reportJobStatus(ctx, job)
+}
+
+// reportJobStatus updates the job status when the reconciliation ends anyway. Errors are only logged, besides
+// conflicts, which need the job to be reconciled again.
+func (r *TestRunReconciler) reportJobStatus(ctx context.Context, job *loadtestingapi.Job) error {
+	err := r.updateJobStatus(ctx, job)
+	if err != nil && !errors.Is(err, errJobChanged) {
+		log.FromContext(ctx).Error(err, "Failed updating job status", "job", job)
+		return nil
+	}
+	return err
+}
+
+// updateJobStatus sends the job status to the API. When the API is unavailable, the update is queued and replayed
+// later.
+func (r *TestRunReconciler) updateJobStatus(ctx context.Context, job *loadtestingapi.Job) error {
+	var err error
+	if r.StatusQueue == nil {
+		err = r.APIClient.UpdateStatus(ctx, job)
+	} else {
+		err = r.StatusQueue.Update(ctx, job)
+	}
+
+	if loadtesting.IsConflict(err) {
+		return fmt.Errorf("%w: %w", errJobChanged, err)
+	}
+	if loadtesting.IsRetryable(err) && r.StatusQueue != nil {
+		// the queue will send it once the API is available again
+		log.FromContext(ctx).Info("API unavailable, status update queued", "status", job.Status, "error", err.Error())
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := r.journalJob(ctx, job); err != nil {
+		log.FromContext(ctx).Error(err, "Failed journaling job state")
+	}
+	return nil
 }
 
 func (r *TestRunReconciler) syncPodDisruptionBudget(ctx context.Context, job *loadtestingapi.Job, parent *batchv1.Job) (*policyv1.PodDisruptionBudget, error) {
@@ -274,6 +420,7 @@ func (r *TestRunReconciler) syncPodDisruptionBudget(ctx context.Context, job *lo
 		obj.Labels["app.kubernetes.io/name"] = "k6"
 		obj.Labels["app.kubernetes.io/instance"] = job.GetName()
 		obj.Labels["app.kubernetes.io/managed-by"] = "orderly-ape"
+		obj.Labels[labelLocation] = r.Location
 
 		err := controllerutil.SetOwnerReference(parent, obj, r.Scheme)
 		if err != nil {
@@ -305,6 +452,7 @@ func (r *TestRunReconciler) syncTelegrafConfig(ctx context.Context, job *loadtes
 				"app.kubernetes.io/name":       "k6",
 				"app.kubernetes.io/instance":   job.GetName(),
 				"app.kubernetes.io/managed-by": "orderly-ape",
+				labelLocation:                  r.Location,
 			},
 		},
 		StringData: map[string]string{
@@ -400,13 +548,14 @@ func (r *TestRunReconciler) syncJob(ctx context.Context, job *loadtestingapi.Job
 		obj.Labels["app.kubernetes.io/name"] = "k6"
 		obj.Labels["app.kubernetes.io/instance"] = job.GetName()
 		obj.Labels["app.kubernetes.io/managed-by"] = "orderly-ape"
+		obj.Labels[labelLocation] = r.Location
 
 		count := int32(len(job.AssignedSegments))
 		ttlSecondsAferFinished := int32(3600) // keep the job for 1 hour after it finishes
 		obj.Spec.TTLSecondsAfterFinished = &ttlSecondsAferFinished
 		obj.Spec.Parallelism = &count
 		obj.Spec.Completions = &count
-		obj.Spec.BackoffLimit = &zero32
+		setFailurePolicy(obj, job)
 		indexed := batchv1.IndexedCompletion
 		obj.Spec.CompletionMode = &indexed
 		if job.TestRun.JobDeadline != nil {
@@ -427,7 +576,8 @@ func (r *TestRunReconciler) syncJob(ctx context.Context, job *loadtestingapi.Job
 			RunAsGroup: &groupID,
 		}
 
-		pod.Spec.NodeSelector = job.TestRun.NodeSelector
+		pod.Spec.NodeSelector = r.nodeSelector(job)
+		pod.Spec.Tolerations = append(pod.Spec.Tolerations, r.Tolerations...)
 
 		if job.TestRun.DedicatedNodes && pod.Spec.Affinity == nil {
 			pod.Spec.Affinity = &corev1.Affinity{
@@ -504,30 +654,7 @@ func (r *TestRunReconciler) syncJob(ctx context.Context, job *loadtestingapi.Job
 		}
 
 		if corev1util.GetContainerByName(pod.Spec.InitContainers, "git") == nil {
-			pod.Spec.InitContainers = corev1util.UpsertContainer(pod.Spec.InitContainers,
-				corev1.Container{
-					Name:            "git",
-					Image:           "alpine/git",
-					ImagePullPolicy: corev1.PullIfNotPresent,
-					WorkingDir:      "/scripts",
-					Command: []string{"/bin/sh", "-c",
-						strings.Join([]string{
-							"mkdir -p /tmp/nobody",
-							"export HOME=/tmp/nobody",
-							"set -eo pipefail",
-							"set -x",
-							"git config --global --add safe.directory '/scripts'",
-							"git init -q",
-							"git remote add origin https://" + job.TestRun.SourceRepo,
-							"git fetch -q --depth=1 origin " + job.TestRun.SourceRef,
-							"git checkout -q FETCH_HEAD",
-						}, "\n"),
-					},
-					VolumeMounts: []corev1.VolumeMount{{
-						Name:      "k6-script",
-						MountPath: "/scripts",
-					}},
-				})
+			pod.Spec.InitContainers = corev1util.UpsertContainer(pod.Spec.InitContainers, gitContainer(job))
 		}
 
 		probe := &corev1.Probe{
@@ -541,18 +668,7 @@ func (r *TestRunReconciler) syncJob(ctx context.Context, job *loadtestingapi.Job
 			},
 		}
 
-		env := []corev1.EnvVar{}
-		for name, value := range job.TestRun.EnvVars {
-			env = corev1util.UpsertEnvVars(env, corev1.EnvVar{
-				Name:  name,
-				Value: value,
-			})
-		}
-		env = corev1util.UpsertEnvVars(env,
-			corev1.EnvVar{
-				Name:  "TARGET",
-				Value: job.TestRun.Target,
-			},
+		env := corev1util.UpsertEnvVars(scriptEnv(job),
 			corev1.EnvVar{
 				Name:  "K6_OUT",
 				Value: "output-statsd",
@@ -590,6 +706,7 @@ func (r *TestRunReconciler) syncJob(ctx context.Context, job *loadtestingapi.Job
                         wait $PID
                         EXIT_CODE=$?
                         echo "k6 finished with code $EXIT_CODE" >&2
+                        echo "$EXIT_CODE" > /dev/termination-log || true
                         echo "Allow telegraf to flush it's metrics" >&2
                         sleep %d
                         echo "Killing telegraf" >&2
@@ -612,10 +729,7 @@ func (r *TestRunReconciler) syncJob(ctx context.Context, job *loadtestingapi.Job
 				LivenessProbe:  probe,
 				ReadinessProbe: probe,
 				Resources: corev1.ResourceRequirements{
-					Requests: corev1.ResourceList{
-						corev1.ResourceCPU:    job.TestRun.ResourceCPU,
-						corev1.ResourceMemory: job.TestRun.ResourceMemory,
-					},
+					Requests: r.workerResources(job),
 				},
 			},
 		)
@@ -652,6 +766,87 @@ func (r *TestRunReconciler) syncJob(ctx context.Context, job *loadtestingapi.Job
 	return obj, err
 }
 
+// nodeSelector returns the node selector of the pods of a job, merging the location and test run ones.
+func (r *TestRunReconciler) nodeSelector(job *loadtestingapi.Job) map[string]string {
+	selector := make(map[string]string, len(r.NodeSelector)+len(job.TestRun.NodeSelector))
+	for key, value := range r.NodeSelector {
+		selector[key] = value
+	}
+	for key, value := range job.TestRun.NodeSelector {
+		selector[key] = value
+	}
+	return selector
+}
+
+// gitContainer returns the init container cloning the test script in the k6-script volume.
+func gitContainer(job *loadtestingapi.Job) corev1.Container {
+	return corev1.Container{
+		Name:            "git",
+		Image:           "alpine/git",
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		WorkingDir:      "/scripts",
+		Command: []string{"/bin/sh", "-c",
+			strings.Join([]string{
+				"mkdir -p /tmp/nobody",
+				"export HOME=/tmp/nobody",
+				"set -eo pipefail",
+				"set -x",
+				"git config --global --add safe.directory '/scripts'",
+				"git init -q",
+				"git remote add origin https://" + job.TestRun.SourceRepo,
+				"git fetch -q --depth=1 origin " + job.TestRun.SourceRef,
+				"git checkout -q FETCH_HEAD",
+			}, "\n"),
+		},
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      "k6-script",
+			MountPath: "/scripts",
+		}},
+	}
+}
+
+// scriptEnv returns the environment variables of the test script.
+func scriptEnv(job *loadtestingapi.Job) []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+	for name, value := range job.TestRun.EnvVars {
+		env = corev1util.UpsertEnvVars(env, corev1.EnvVar{
+			Name:  name,
+			Value: value,
+		})
+	}
+	return corev1util.UpsertEnvVars(env, corev1.EnvVar{
+		Name:  "TARGET",
+		Value: job.TestRun.Target,
+	})
+}
+
+// workerResources returns the resource requests of the worker pods, falling back to the location defaults.
+func (r *TestRunReconciler) workerResources(job *loadtestingapi.Job) corev1.ResourceList {
+	resources := corev1.ResourceList{}
+	for name, quantity := range r.WorkerResources {
+		resources[name] = quantity.DeepCopy()
+	}
+
+	if !job.TestRun.ResourceCPU.IsZero() || resources.Cpu().IsZero() {
+		resources[corev1.ResourceCPU] = job.TestRun.ResourceCPU
+	}
+	if !job.TestRun.ResourceMemory.IsZero() || resources.Memory().IsZero() {
+		resources[corev1.ResourceMemory] = job.TestRun.ResourceMemory
+	}
+
+	// automatic sizing overrides both
+	if job.Sizing != nil && job.Sizing.Applied {
+		if !job.Sizing.CPU.IsZero() {
+			resources[corev1.ResourceCPU] = job.Sizing.CPU
+		}
+		if !job.Sizing.Memory.IsZero() {
+			resources[corev1.ResourceMemory] = job.Sizing.Memory
+		}
+	}
+
+	return resources
+}
+
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 
 func (r *TestRunReconciler) getPods(ctx context.Context, job *loadtestingapi.Job) ([]corev1.Pod, error) {
@@ -686,25 +881,53 @@ func isPodFailed(pod *corev1.Pod) bool {
 	return pod.Status.Phase == corev1.PodFailed
 }
 
+// podStabilityPeriod is how long pods need to be ready before starting the test.
+const podStabilityPeriod = 5 * time.Second
+
 func isPodStableReady(pod *corev1.Pod) bool {
 	now := time.Now()
-	stabilityPeriod := 5 * time.Second
 
 	for _, condition := range pod.Status.Conditions {
-		if condition.Type == corev1.PodReady && condition.LastTransitionTime.Add(stabilityPeriod).Before(now) {
+		if condition.Type == corev1.PodReady && condition.LastTransitionTime.Add(podStabilityPeriod).Before(now) {
 			return condition.Status == corev1.ConditionTrue
 		}
 	}
 	return false
 }
 
+// Notify reconciles a job right away. It's used when the webapp notifies a change, instead of waiting for the
+// watcher to find it.
+func (r *TestRunReconciler) Notify(ctx context.Context, name string) error {
+	if r.worker == nil {
+		return fmt.Errorf("controller for %s is not set up", r.Location)
+	}
+	return r.worker.Notify(ctx, name)
+}
+
+// owns returns true if the resource was created for the location of the reconciler. Unlabelled resources are only
+// claimed when no other location could have created them.
+func (r *TestRunReconciler) owns(obj client.Object) bool {
+	location, found := obj.GetLabels()[labelLocation]
+	if found {
+		return location == r.Location
+	}
+	return r.Exclusive
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *TestRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	clientset, err := clientset.NewForConfig(mgr.GetConfig())
-	if err != nil {
-		return err
+	if r.PodDialer == nil || r.PodLogs == nil {
+		dialer, err := k6.NewProxyDialer(mgr.GetConfig())
+		if err != nil {
+			return err
+		}
+		if r.PodDialer == nil {
+			r.PodDialer = dialer
+		}
+		if r.PodLogs == nil {
+			r.PodLogs = dialer
+		}
 	}
-	r.clientset = clientset
 
 	worker, err := loadtesting.NewWorker(r.APIClient, &loadtestingapi.Job{}, func(obj loadtestingruntime.Object) bool {
 		job, ok := obj.(*loadtestingapi.Job)
@@ -725,25 +948,35 @@ func (r *TestRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err = mgr.Add(worker); err != nil {
 		return err
 	}
+	r.worker = worker
 
-	r.igniters = make(Igniters)
+	r.igniters = NewIgniters()
+	r.failures = NewPodFailures()
 
 	managedByOrderlyApe, err := predicate.LabelSelectorPredicate(
 		metav1.LabelSelector{
 			MatchLabels: map[string]string{
 				"app.kubernetes.io/managed-by": "orderly-ape",
 			},
+			// the jobs inspecting the test scripts are polled while sizing
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "app.kubernetes.io/component",
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{inspectComponent},
+			}},
 		},
 	)
 	if err != nil {
 		return err
 	}
 
+	// locations can share namespaces, so their Jobs are told apart by label
+	predicates := []predicate.Predicate{managedByOrderlyApe, predicate.NewPredicateFuncs(r.owns)}
+
 	return ctrl.NewControllerManagedBy(mgr).
+		Named(fmt.Sprintf("testrun-%s", r.Location)).
 		For(&batchv1.Job{},
-			builder.WithPredicates(
-				managedByOrderlyApe,
-			),
+			builder.WithPredicates(predicates...),
 		).
 		WatchesRawSource(&source.Channel{Source: worker.C}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
 			return []ctrl.Request{
@@ -753,5 +986,9 @@ func (r *TestRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				}},
 			}
 		})).
+		WatchesRawSource(&source.Channel{Source: r.igniters.C}, &handler.EnqueueRequestForObject{}).
+		WithOptions(crcontroller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		}).
 		Complete(r)
 }
@@ -12,6 +12,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/fake"
+
 	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -29,6 +31,8 @@ var _ = Describe("TestRun Controller", func() {
 		job := &batchv1.Job{}
 
 		BeforeEach(func() {
+			requireEnvtest()
+
 			By("creating the custom resource for the Kind TestRun")
 			err := k8sClient.Get(ctx, typeNamespacedName, job)
 			if err != nil && errors.IsNotFound(err) {
@@ -44,6 +48,10 @@ var _ = Describe("TestRun Controller", func() {
 		})
 
 		AfterEach(func() {
+			if testEnv == nil {
+				return
+			}
+
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
 			resource := &batchv1.Job{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
@@ -55,16 +63,20 @@ var _ = Describe("TestRun Controller", func() {
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
 			controllerReconciler := &TestRunReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:    k8sClient,
+				Scheme:    k8sClient.Scheme(),
+				Location:  "test",
+				APIClient: fake.NewAPI().Client("test"),
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: typeNamespacedName,
 			})
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+
+			By("Waiting for the API to confirm the job was removed")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, job)).To(Succeed())
+			Expect(job.Annotations).To(HaveKeyWithValue(annotationNotFound, "1"))
 		})
 	})
 })
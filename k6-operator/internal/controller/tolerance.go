@@ -0,0 +1,144 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+// setFailurePolicy sets how the Kubernetes Job handles failed worker pods. Pods evicted by a node disruption (drain,
+// preemption, ...) don't count as failures and are replaced. Other failures fail the Job, unless the test run
+// tolerates failed segments, in which case the Job keeps running the other segments until more than the allowed
+// number of them failed.
+func setFailurePolicy(obj *batchv1.Job, job *loadtestingapi.Job) {
+	obj.Spec.PodFailurePolicy = &batchv1.PodFailurePolicy{
+		Rules: []batchv1.PodFailurePolicyRule{
+			{
+				Action: batchv1.PodFailurePolicyActionIgnore,
+				OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{
+					{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue},
+				},
+			},
+		},
+	}
+
+	allowed := int32(job.TestRun.GetAllowedFailures(len(job.AssignedSegments)))
+	if allowed == 0 {
+		obj.Spec.BackoffLimit = &zero32
+		obj.Spec.BackoffLimitPerIndex = nil
+		obj.Spec.MaxFailedIndexes = nil
+		return
+	}
+
+	// the backoff limit defaults to unlimited once failures are counted per index
+	obj.Spec.BackoffLimit = nil
+	obj.Spec.BackoffLimitPerIndex = &zero32
+	obj.Spec.MaxFailedIndexes = &allowed
+}
+
+// isToleratedFailure returns true if the Kubernetes Job failed only because some of its indexes failed, within the
+// failure tolerance. The outcome of the test run is then decided once all the worker pods are done.
+func isToleratedFailure(cond *batchv1.JobCondition) bool {
+	return cond.Reason == batchv1.JobReasonFailedIndexes
+}
+
+// hasFailedIndexes returns true if any index of the Kubernetes Job failed. It's only tracked when the test run
+// tolerates failed segments.
+func hasFailedIndexes(obj *batchv1.Job) bool {
+	return obj.Status.FailedIndexes != nil && *obj.Status.FailedIndexes != ""
+}
+
+// lostSegments returns the segments of a job which weren't completed by any worker pod, or whose worker pod was
+// disrupted after the test started.
+func lostSegments(job *loadtestingapi.Job, obj *batchv1.Job) []loadtestingapi.Segment {
+	completed := parseIndexes(obj.Status.CompletedIndexes)
+
+	lost := []loadtestingapi.Segment{}
+	for idx, segment := range job.AssignedSegments {
+		if !completed[idx] || slices.Contains(job.DisruptedSegments, segment) {
+			lost = append(lost, segment)
+		}
+	}
+	return lost
+}
+
+// completeJob decides the outcome of a job once none of its worker pods is running anymore. Lost segments within
+// the failure tolerance complete the test as degraded, so the missing part of the load is known.
+func completeJob(job *loadtestingapi.Job, obj *batchv1.Job) {
+	lost := lostSegments(job, obj)
+	total := len(job.AssignedSegments)
+	job.LostSegments = nil
+	if len(lost) > 0 {
+		job.LostSegments = lost
+	}
+
+	switch {
+	case len(lost) == 0:
+		job.Status = loadtestingapi.STATUS_COMPLETED
+		job.StatusDescription = "Worker pods have successfully completed running k6 tests"
+	case len(lost) <= job.TestRun.GetAllowedFailures(total):
+		message := fmt.Sprintf("%d/%d segments were lost: %s", len(lost), total, describeLostSegments(job, lost))
+		job.SetCondition(loadtestingapi.CONDITION_DEGRADED, loadtestingapi.CONDITION_TRUE, "SegmentsLost", message)
+		job.Status = loadtestingapi.STATUS_COMPLETED
+		job.StatusDescription = fmt.Sprintf("Worker pods have completed running k6 tests, but %s", message)
+	default:
+		job.Status = loadtestingapi.STATUS_FAILED
+		job.StatusDescription = fmt.Sprintf("Worker pods have failed running k6 tests, %d/%d segments were lost: %s",
+			len(lost), total, describeLostSegments(job, lost))
+	}
+}
+
+// describeSegments lists segments with their ID and range, ex. "2 (1/3:2/3), 3 (2/3:1)".
+func describeSegments(segments []loadtestingapi.Segment) string {
+	parts := make([]string, len(segments))
+	for idx, segment := range segments {
+		parts[idx] = fmt.Sprintf("%s (%s)", segment.ID, segment.Segment)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeLostSegments lists lost segments like describeSegments, telling apart the ones lost to a node disruption,
+// ex. "2 (1/3:2/3), 3 (2/3:1, disrupted)".
+func describeLostSegments(job *loadtestingapi.Job, segments []loadtestingapi.Segment) string {
+	parts := make([]string, len(segments))
+	for idx, segment := range segments {
+		parts[idx] = fmt.Sprintf("%s (%s)", segment.ID, segment.Segment)
+		if slices.Contains(job.DisruptedSegments, segment) {
+			parts[idx] = fmt.Sprintf("%s (%s, disrupted)", segment.ID, segment.Segment)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseIndexes parses the indexes of a Kubernetes Job status, formatted as comma separated intervals
+// (ex. "0,2-4"). Malformed intervals are skipped.
+func parseIndexes(indexes string) map[int]bool {
+	result := map[int]bool{}
+	for _, interval := range strings.Split(indexes, ",") {
+		first, last, found := strings.Cut(strings.TrimSpace(interval), "-")
+		if !found {
+			last = first
+		}
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			continue
+		}
+		end, err := strconv.Atoi(last)
+		if err != nil {
+			continue
+		}
+		for idx := start; idx <= end; idx++ {
+			result[idx] = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+//  SPDX-License-Identifier: MIT
+//  SPDX-FileCopyrightText: 2024 ReviewSignal
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+
+	loadtestingapi "github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+var _ = Describe("Failure tolerance", func() {
+	var (
+		job *loadtestingapi.Job
+		obj *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		job = &loadtestingapi.Job{
+			Name:   "test-run",
+			Status: loadtestingapi.STATUS_RUNNING,
+			AssignedSegments: []loadtestingapi.Segment{
+				{ID: "1", Segment: "0:1/4"},
+				{ID: "2", Segment: "1/4:1/2"},
+				{ID: "3", Segment: "1/2:3/4"},
+				{ID: "4", Segment: "3/4:1"},
+			},
+		}
+		obj = &batchv1.Job{}
+	})
+
+	DescribeTable("allows a number or a percentage of the segments to fail",
+		func(tolerance string, allowed int) {
+			job.TestRun.FailureTolerance = tolerance
+			Expect(job.TestRun.GetAllowedFailures(len(job.AssignedSegments))).To(Equal(allowed))
+		},
+		Entry("none by default", "", 0),
+		Entry("a number", "2", 2),
+		Entry("a percentage, rounded down", "30%", 1),
+		Entry("at least one succeeding segment", "100%", 3),
+		Entry("nothing when malformed", "some", 0),
+	)
+
+	It("fails the Kubernetes Job on the first failure by default", func() {
+		setFailurePolicy(obj, job)
+
+		Expect(*obj.Spec.BackoffLimit).To(BeZero())
+		Expect(obj.Spec.MaxFailedIndexes).To(BeNil())
+		Expect(obj.Spec.PodFailurePolicy.Rules[0].Action).To(Equal(batchv1.PodFailurePolicyActionIgnore))
+	})
+
+	It("counts the failures per segment when tolerated", func() {
+		job.TestRun.FailureTolerance = "50%"
+
+		setFailurePolicy(obj, job)
+
+		Expect(obj.Spec.BackoffLimit).To(BeNil())
+		Expect(*obj.Spec.BackoffLimitPerIndex).To(BeZero())
+		Expect(*obj.Spec.MaxFailedIndexes).To(Equal(int32(2)))
+	})
+
+	It("completes when all the segments completed", func() {
+		obj.Status.CompletedIndexes = "0-3"
+
+		completeJob(job, obj)
+
+		Expect(job.Status).To(Equal(loadtestingapi.STATUS_COMPLETED))
+		Expect(job.LostSegments).To(BeNil())
+		Expect(job.GetCondition(loadtestingapi.CONDITION_DEGRADED)).To(BeNil())
+	})
+
+	It("completes as degraded when the lost segments are tolerated", func() {
+		job.TestRun.FailureTolerance = "1"
+		obj.Status.CompletedIndexes = "0,2-3"
+
+		completeJob(job, obj)
+
+		Expect(job.Status).To(Equal(loadtestingapi.STATUS_COMPLETED))
+		Expect(job.LostSegments).To(Equal([]loadtestingapi.Segment{{ID: "2", Segment: "1/4:1/2"}}))
+		Expect(job.IsConditionTrue(loadtestingapi.CONDITION_DEGRADED)).To(BeTrue())
+		Expect(job.StatusDescription).To(ContainSubstring("1/4 segments were lost: 2 (1/4:1/2)"))
+	})
+
+	It("fails when more segments were lost than tolerated", func() {
+		job.TestRun.FailureTolerance = "1"
+		obj.Status.CompletedIndexes = "1-2"
+
+		completeJob(job, obj)
+
+		Expect(job.Status).To(Equal(loadtestingapi.STATUS_FAILED))
+		Expect(job.StatusDescription).To(ContainSubstring("2/4 segments were lost: 1 (0:1/4), 4 (3/4:1)"))
+	})
+})
@@ -13,6 +13,111 @@ type StatusData struct {
 type StatusAttributes struct {
 	Paused  *bool `json:"paused,omitempty"`
 	Stopped *bool `json:"stopped,omitempty"`
+	Running *bool `json:"running,omitempty"`
+	Tainted *bool `json:"tainted,omitempty"`
 	Vus     *int  `json:"vus,omitempty"`
 	VusMax  *int  `json:"vus-max,omitempty"`
 }
+
+// IsRunning returns true if k6 reports the test as un-paused and running.
+func (s *StatusAttributes) IsRunning() bool {
+	return s.Paused != nil && !*s.Paused && s.Running != nil && *s.Running
+}
+
+// ErrorResponse is returned by k6 when a request fails.
+type ErrorResponse struct {
+	Errors []Error `json:"errors"`
+}
+
+type Error struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type MetricsResponse struct {
+	Data []Metric `json:"data"`
+}
+
+type MetricResponse struct {
+	Data Metric `json:"data"`
+}
+
+// Metric is a metric collected by k6, like http_reqs, with its current value.
+type Metric struct {
+	Type       string           `json:"type"`
+	ID         string           `json:"id"`
+	Attributes MetricAttributes `json:"attributes"`
+}
+
+type MetricAttributes struct {
+	// Type is counter, gauge, rate or trend.
+	Type     string `json:"type"`
+	Contains string `json:"contains"`
+	Tainted  *bool  `json:"tainted"`
+	// Sample holds the aggregated values, like count and rate for counters, or avg, p(95) for trends.
+	Sample map[string]float64 `json:"sample"`
+}
+
+type GroupsResponse struct {
+	Data []Group `json:"data"`
+}
+
+type GroupResponse struct {
+	Data Group `json:"data"`
+}
+
+// Group is a group of a k6 script, with the results of its checks. The root group has an empty path.
+type Group struct {
+	Type          string             `json:"type"`
+	ID            string             `json:"id"`
+	Attributes    GroupAttributes    `json:"attributes"`
+	Relationships GroupRelationships `json:"relationships"`
+}
+
+type GroupAttributes struct {
+	Path   string  `json:"path"`
+	Name   string  `json:"name"`
+	Checks []Check `json:"checks"`
+}
+
+type Check struct {
+	ID     string `json:"id"`
+	Path   string `json:"path"`
+	Name   string `json:"name"`
+	Passes int64  `json:"passes"`
+	Fails  int64  `json:"fails"`
+}
+
+type GroupRelationships struct {
+	Groups GroupRefs `json:"groups"`
+	Parent GroupRef  `json:"parent"`
+}
+
+type GroupRefs struct {
+	Data []ResourceRef `json:"data"`
+}
+
+type GroupRef struct {
+	Data *ResourceRef `json:"data"`
+}
+
+type ResourceRef struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// ExecutionRequirements is the output of `k6 inspect --execution-requirements`: the options of the script, along
+// with the maximum number of VUs and the total duration of the test.
+type ExecutionRequirements struct {
+	Scenarios     map[string]Scenario `json:"scenarios"`
+	MaxVUs        int64               `json:"maxVUs"`
+	TotalDuration string              `json:"totalDuration"`
+}
+
+// Scenario is the shape of a scenario of the script. Only the fields common to all the executors are kept.
+type Scenario struct {
+	Executor  string `json:"executor"`
+	StartTime string `json:"startTime,omitempty"`
+	Exec      string `json:"exec,omitempty"`
+}
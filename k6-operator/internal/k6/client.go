@@ -0,0 +1,156 @@
+// Package k6 talks to the REST API of the k6 instances running in the worker pods.
+package k6
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/api"
+)
+
+// PodDialer sends requests to the k6 REST API of a pod.
+type PodDialer interface {
+	// Do sends a request to the k6 REST API of a pod and returns the response body. path is relative to the root
+	// of the API, like /v1/status.
+	Do(ctx context.Context, namespace string, pod string, method string, path string, body []byte) ([]byte, error)
+}
+
+// PodLogReader reads the logs of a container.
+type PodLogReader interface {
+	Logs(ctx context.Context, namespace string, pod string, container string) ([]byte, error)
+}
+
+// ProxyDialer reaches the pods through the pods/proxy subresource of the Kubernetes API, so the operator doesn't
+// need to be able to connect to the pods network.
+type ProxyDialer struct {
+	Client rest.Interface
+}
+
+// NewProxyDialer instantiates a ProxyDialer using the Kubernetes API config.
+func NewProxyDialer(config *rest.Config) (*ProxyDialer, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProxyDialer{Client: clientset.CoreV1().RESTClient()}, nil
+}
+
+func (d *ProxyDialer) Do(ctx context.Context, namespace string, pod string, method string, path string, body []byte) ([]byte, error) {
+	req := d.Client.Verb(method).
+		Resource("pods").
+		SubResource("proxy").
+		Namespace(namespace).
+		Name(pod).
+		Suffix(path)
+	if body != nil {
+		req = req.SetHeader("Content-Type", "application/json").Body(body)
+	}
+
+	return req.DoRaw(ctx)
+}
+
+// Logs reads the logs of a container through the pods/log subresource.
+func (d *ProxyDialer) Logs(ctx context.Context, namespace string, pod string, container string) ([]byte, error) {
+	return d.Client.Get().
+		Resource("pods").
+		SubResource("log").
+		Namespace(namespace).
+		Name(pod).
+		Param("container", container).
+		DoRaw(ctx)
+}
+
+// StatusError is returned by dialers when k6 responds with an error.
+type StatusError struct {
+	Code int
+	Body []byte
+}
+
+func (e *StatusError) Error() string {
+	errResp := api.ErrorResponse{}
+	if err := json.Unmarshal(e.Body, &errResp); err == nil && len(errResp.Errors) > 0 {
+		return fmt.Sprintf("k6 responded with status code %d: %s", e.Code, errResp.Errors[0].Title)
+	}
+	return fmt.Sprintf("k6 responded with status code %d", e.Code)
+}
+
+// Client is a client for the k6 REST API of the worker pods.
+type Client struct {
+	dialer PodDialer
+}
+
+// NewClient instantiates a client sending its requests through dialer.
+func NewClient(dialer PodDialer) *Client {
+	return &Client{dialer: dialer}
+}
+
+// Status retrieves the status of the k6 instance running in a pod.
+func (c *Client) Status(ctx context.Context, namespace string, pod string) (*api.StatusAttributes, error) {
+	status := api.StatusRequest{}
+	if err := c.do(ctx, namespace, pod, "GET", "/v1/status", nil, &status); err != nil {
+		return nil, err
+	}
+
+	return &status.Data.Attributes, nil
+}
+
+// PatchStatus updates the status of the k6 instance running in a pod. It's used to pause, un-pause and stop the
+// test, or to change the number of VUs.
+func (c *Client) PatchStatus(ctx context.Context, namespace string, pod string, attributes api.StatusAttributes) (*api.StatusAttributes, error) {
+	req := api.StatusRequest{
+		Data: api.StatusData{
+			ID:         "default",
+			Type:       "status",
+			Attributes: attributes,
+		},
+	}
+
+	status := api.StatusRequest{}
+	if err := c.do(ctx, namespace, pod, "PATCH", "/v1/status", req, &status); err != nil {
+		return nil, err
+	}
+
+	return &status.Data.Attributes, nil
+}
+
+// Metrics retrieves the current value of all the metrics of the k6 instance running in a pod.
+func (c *Client) Metrics(ctx context.Context, namespace string, pod string) ([]api.Metric, error) {
+	metrics := api.MetricsResponse{}
+	if err := c.do(ctx, namespace, pod, "GET", "/v1/metrics", nil, &metrics); err != nil {
+		return nil, err
+	}
+
+	return metrics.Data, nil
+}
+
+// Groups retrieves the groups of the script run by the k6 instance in a pod, with the results of their checks.
+func (c *Client) Groups(ctx context.Context, namespace string, pod string) ([]api.Group, error) {
+	groups := api.GroupsResponse{}
+	if err := c.do(ctx, namespace, pod, "GET", "/v1/groups", nil, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups.Data, nil
+}
+
+func (c *Client) do(ctx context.Context, namespace string, pod string, method string, path string, req interface{}, resp interface{}) error {
+	var body []byte
+	if req != nil {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			return err
+		}
+	}
+
+	data, err := c.dialer.Do(ctx, namespace, pod, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, resp)
+}
@@ -0,0 +1,83 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6"
+)
+
+// Dialer is a k6.PodDialer routing the requests to fake k6 instances, without any network involved.
+type Dialer struct {
+	mu   sync.Mutex
+	pods map[string]*K6
+	logs map[string][]byte
+}
+
+var (
+	_ k6.PodDialer    = &Dialer{}
+	_ k6.PodLogReader = &Dialer{}
+)
+
+// NewDialer instantiates a dialer without any pods.
+func NewDialer() *Dialer {
+	return &Dialer{pods: map[string]*K6{}, logs: map[string][]byte{}}
+}
+
+// SetLogs sets the logs of a container.
+func (d *Dialer) SetLogs(namespace string, pod string, container string, logs []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.logs[namespace+"/"+pod+"/"+container] = logs
+}
+
+func (d *Dialer) Logs(ctx context.Context, namespace string, pod string, container string) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	logs, found := d.logs[namespace+"/"+pod+"/"+container]
+	if !found {
+		return nil, &k6.StatusError{Code: http.StatusNotFound}
+	}
+	return logs, nil
+}
+
+// Add registers the k6 instance running in a pod.
+func (d *Dialer) Add(namespace string, pod string, instance *K6) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pods[namespace+"/"+pod] = instance
+}
+
+// Pod returns the k6 instance running in a pod, or nil.
+func (d *Dialer) Pod(namespace string, pod string) *K6 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.pods[namespace+"/"+pod]
+}
+
+func (d *Dialer) Do(ctx context.Context, namespace string, pod string, method string, path string, body []byte) ([]byte, error) {
+	instance := d.Pod(namespace, pod)
+	if instance == nil {
+		// the pods/proxy subresource reports missing pods like this
+		return nil, &k6.StatusError{Code: http.StatusNotFound}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(body)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	instance.Handler().ServeHTTP(rec, req)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if rec.Code < 200 || rec.Code > 299 {
+		return nil, &k6.StatusError{Code: rec.Code, Body: rec.Body.Bytes()}
+	}
+	return rec.Body.Bytes(), nil
+}
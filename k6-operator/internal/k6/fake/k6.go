@@ -0,0 +1,436 @@
+// Package fake implements the REST API of k6 in memory, so the interactions of the operator with the worker pods
+// can be tested without running k6.
+package fake
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/api"
+)
+
+// Failure makes requests fail with an HTTP error code. Empty fields match all the requests.
+type Failure struct {
+	Method string
+	Path   string
+	Code   int
+	// Times is how many requests fail. Zero fails all of them.
+	Times int
+}
+
+func (f *Failure) matches(r *http.Request) bool {
+	return (f.Method == "" || f.Method == r.Method) && (f.Path == "" || f.Path == r.URL.Path)
+}
+
+// Request is a request received by k6.
+type Request struct {
+	Method string
+	Path   string
+	Body   string
+	At     time.Time
+}
+
+// K6 is a fake k6 instance. Like the worker pods, it starts paused, waiting to be un-paused through its REST API.
+type K6 struct {
+	mu       sync.Mutex
+	paused   bool
+	running  bool
+	stopped  bool
+	tainted  bool
+	vus      int
+	vusMax   int
+	metrics  map[string]api.Metric
+	groups   map[string]*api.Group
+	latency  time.Duration
+	failures []*Failure
+	requests []Request
+}
+
+// New instantiates a paused k6 instance, with vusMax VUs initialized.
+func New(vusMax int) *K6 {
+	k := &K6{
+		paused:  true,
+		vus:     vusMax,
+		vusMax:  vusMax,
+		metrics: map[string]api.Metric{},
+		groups:  map[string]*api.Group{},
+	}
+	k.groups[groupID("")] = &api.Group{
+		Type: "groups",
+		ID:   groupID(""),
+		Attributes: api.GroupAttributes{
+			Checks: []api.Check{},
+		},
+		Relationships: api.GroupRelationships{
+			Groups: api.GroupRefs{Data: []api.ResourceRef{}},
+		},
+	}
+	k.SetMetric("http_reqs", "counter", map[string]float64{"count": 0, "rate": 0})
+
+	return k
+}
+
+// Status returns the current status.
+func (k *K6) Status() api.StatusAttributes {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.status()
+}
+
+// Finish ends the test, as if all its scenarios completed.
+func (k *K6) Finish() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.running = false
+}
+
+// Taint marks the test as tainted, as k6 does when thresholds are crossed.
+func (k *K6) Taint() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.tainted = true
+}
+
+// SetLatency delays all the following responses.
+func (k *K6) SetLatency(latency time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.latency = latency
+}
+
+// Fail makes the matching requests fail. Failures are checked in the order they were added.
+func (k *K6) Fail(failure Failure) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.failures = append(k.failures, &failure)
+}
+
+// SetMetric sets the current value of a metric.
+func (k *K6) SetMetric(name string, metricType string, sample map[string]float64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.metrics[name] = api.Metric{
+		Type: "metrics",
+		ID:   name,
+		Attributes: api.MetricAttributes{
+			Type:     metricType,
+			Contains: "default",
+			Sample:   sample,
+		},
+	}
+}
+
+// AddGroup adds a group to the root group of the script.
+func (k *K6) AddGroup(name string, checks ...api.Check) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	path := "::" + name
+	for idx := range checks {
+		check := &checks[idx]
+		check.Path = path + "::" + check.Name
+		check.ID = groupID(check.Path)
+	}
+
+	root := k.groups[groupID("")]
+	group := &api.Group{
+		Type: "groups",
+		ID:   groupID(path),
+		Attributes: api.GroupAttributes{
+			Path:   path,
+			Name:   name,
+			Checks: checks,
+		},
+		Relationships: api.GroupRelationships{
+			Groups: api.GroupRefs{Data: []api.ResourceRef{}},
+			Parent: api.GroupRef{Data: &api.ResourceRef{Type: "groups", ID: root.ID}},
+		},
+	}
+	k.groups[group.ID] = group
+	root.Relationships.Groups.Data = append(root.Relationships.Groups.Data, api.ResourceRef{Type: "groups", ID: group.ID})
+}
+
+// Requests returns the requests received so far.
+func (k *K6) Requests() []Request {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return append([]Request{}, k.requests...)
+}
+
+// Handler returns the HTTP handler of the REST API.
+func (k *K6) Handler() http.Handler {
+	return http.HandlerFunc(k.serveHTTP)
+}
+
+func (k *K6) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body := []byte{}
+	if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+	}
+
+	k.mu.Lock()
+	k.requests = append(k.requests, Request{Method: r.Method, Path: r.URL.Path, Body: string(body), At: time.Now()})
+	latency := k.latency
+	failure := k.failure(r)
+	k.mu.Unlock()
+
+	if latency > 0 {
+		if err := sleep(r.Context(), latency); err != nil {
+			return
+		}
+	}
+	if failure != 0 {
+		writeError(w, failure, http.StatusText(failure))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "v1" && parts[1] == "status":
+		k.serveStatus(w, r, body)
+	case len(parts) == 2 && parts[0] == "v1" && parts[1] == "metrics" && r.Method == http.MethodGet:
+		k.mu.Lock()
+		metrics := api.MetricsResponse{Data: k.metricList()}
+		k.mu.Unlock()
+		writeJSON(w, http.StatusOK, metrics)
+	case len(parts) == 3 && parts[0] == "v1" && parts[1] == "metrics" && r.Method == http.MethodGet:
+		k.mu.Lock()
+		metric, found := k.metricByID(parts[2])
+		k.mu.Unlock()
+		if !found {
+			writeError(w, http.StatusNotFound, "no metric with that id")
+			return
+		}
+		writeJSON(w, http.StatusOK, api.MetricResponse{Data: metric})
+	case len(parts) == 2 && parts[0] == "v1" && parts[1] == "groups" && r.Method == http.MethodGet:
+		// groups share their slices, so they are encoded while holding the lock
+		k.mu.Lock()
+		data, _ := json.Marshal(api.GroupsResponse{Data: k.groupList()})
+		k.mu.Unlock()
+		writeRaw(w, http.StatusOK, data)
+	case len(parts) == 3 && parts[0] == "v1" && parts[1] == "groups" && r.Method == http.MethodGet:
+		k.mu.Lock()
+		group, found := k.groups[parts[2]]
+		var data []byte
+		if found {
+			data, _ = json.Marshal(api.GroupResponse{Data: *group})
+		}
+		k.mu.Unlock()
+		if !found {
+			writeError(w, http.StatusNotFound, "no group with that id")
+			return
+		}
+		writeRaw(w, http.StatusOK, data)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (k *K6) serveStatus(w http.ResponseWriter, r *http.Request, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPatch:
+		req := api.StatusRequest{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if err := k.patch(req.Data.Attributes); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	k.mu.Lock()
+	status := k.status()
+	k.mu.Unlock()
+	writeJSON(w, http.StatusOK, api.StatusRequest{
+		Data: api.StatusData{ID: "default", Type: "status", Attributes: status},
+	})
+}
+
+// patch applies a status change, with the same rules as k6.
+func (k *K6) patch(attributes api.StatusAttributes) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if attributes.Stopped != nil && *attributes.Stopped {
+		k.stopped = true
+		k.running = false
+	}
+
+	if attributes.Paused != nil {
+		if k.stopped {
+			return fmt.Errorf("test run was stopped")
+		}
+		if !*attributes.Paused && k.paused {
+			k.running = true
+		}
+		k.paused = *attributes.Paused
+	}
+
+	if attributes.VusMax != nil {
+		if *attributes.VusMax < k.vus {
+			return fmt.Errorf("can't lower vu cap (to %d) below vu count (%d)", *attributes.VusMax, k.vus)
+		}
+		k.vusMax = *attributes.VusMax
+	}
+
+	if attributes.Vus != nil {
+		if *attributes.Vus > k.vusMax {
+			return fmt.Errorf("can't raise vu count (to %d) above vu cap (%d)", *attributes.Vus, k.vusMax)
+		}
+		if *attributes.Vus < 0 {
+			return fmt.Errorf("vu count can't be negative")
+		}
+		k.vus = *attributes.Vus
+	}
+
+	return nil
+}
+
+func (k *K6) status() api.StatusAttributes {
+	paused, stopped, running, tainted := k.paused, k.stopped, k.running, k.tainted
+	vus, vusMax := k.vus, k.vusMax
+
+	return api.StatusAttributes{
+		Paused:  &paused,
+		Stopped: &stopped,
+		Running: &running,
+		Tainted: &tainted,
+		Vus:     &vus,
+		VusMax:  &vusMax,
+	}
+}
+
+// failure returns the status code of the first failure matching the request, or zero.
+func (k *K6) failure(r *http.Request) int {
+	for idx, failure := range k.failures {
+		if !failure.matches(r) {
+			continue
+		}
+		if failure.Times > 0 {
+			failure.Times--
+			if failure.Times == 0 {
+				k.failures = append(k.failures[:idx], k.failures[idx+1:]...)
+			}
+		}
+		return failure.Code
+	}
+	return 0
+}
+
+func (k *K6) metricList() []api.Metric {
+	names := make([]string, 0, len(k.metrics)+2)
+	for name := range k.metrics {
+		names = append(names, name)
+	}
+	names = append(names, "vus", "vus_max")
+	sort.Strings(names)
+
+	metrics := make([]api.Metric, 0, len(names))
+	for _, name := range names {
+		metric, _ := k.metricByID(name)
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+// metricByID returns a metric. The vus and vus_max gauges follow the status.
+func (k *K6) metricByID(id string) (api.Metric, bool) {
+	gauge := func(value int) api.Metric {
+		return api.Metric{
+			Type: "metrics",
+			ID:   id,
+			Attributes: api.MetricAttributes{
+				Type:     "gauge",
+				Contains: "default",
+				Sample:   map[string]float64{"value": float64(value)},
+			},
+		}
+	}
+
+	switch id {
+	case "vus":
+		return gauge(k.vus), true
+	case "vus_max":
+		return gauge(k.vusMax), true
+	}
+
+	metric, found := k.metrics[id]
+	if found {
+		sample := make(map[string]float64, len(metric.Attributes.Sample))
+		for key, value := range metric.Attributes.Sample {
+			sample[key] = value
+		}
+		metric.Attributes.Sample = sample
+	}
+	return metric, found
+}
+
+func (k *K6) groupList() []api.Group {
+	groups := make([]api.Group, 0, len(k.groups))
+	for _, group := range k.groups {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Attributes.Path < groups[j].Attributes.Path
+	})
+	return groups
+}
+
+// groupID returns the ID k6 gives to a group or check: the MD5 hash of its path.
+func groupID(path string) string {
+	sum := md5.Sum([]byte(path))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeRaw(w http.ResponseWriter, code int, data []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, code int, title string) {
+	writeJSON(w, code, api.ErrorResponse{
+		Errors: []api.Error{{Status: strconv.Itoa(code), Title: title}},
+	})
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
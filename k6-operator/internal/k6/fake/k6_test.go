@@ -0,0 +1,115 @@
+package fake
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/api"
+)
+
+var _ = Describe("K6", func() {
+	var (
+		ctx      context.Context
+		instance *K6
+		client   *k6.Client
+	)
+
+	boolPtr := func(b bool) *bool { return &b }
+	intPtr := func(i int) *int { return &i }
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		instance = New(10)
+
+		dialer := NewDialer()
+		dialer.Add("default", "pod-1", instance)
+		client = k6.NewClient(dialer)
+	})
+
+	It("starts paused and runs once un-paused", func() {
+		status, err := client.Status(ctx, "default", "pod-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*status.Paused).To(BeTrue())
+		Expect(status.IsRunning()).To(BeFalse())
+
+		status, err = client.PatchStatus(ctx, "default", "pod-1", api.StatusAttributes{Paused: boolPtr(false)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.IsRunning()).To(BeTrue())
+
+		instance.Finish()
+		status, err = client.Status(ctx, "default", "pod-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.IsRunning()).To(BeFalse())
+	})
+
+	It("can't be resumed once stopped", func() {
+		status, err := client.PatchStatus(ctx, "default", "pod-1", api.StatusAttributes{Stopped: boolPtr(true)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*status.Stopped).To(BeTrue())
+
+		_, err = client.PatchStatus(ctx, "default", "pod-1", api.StatusAttributes{Paused: boolPtr(false)})
+		Expect(err).To(MatchError(ContainSubstring("test run was stopped")))
+	})
+
+	It("scales the VUs up to the cap", func() {
+		status, err := client.PatchStatus(ctx, "default", "pod-1", api.StatusAttributes{Vus: intPtr(4)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*status.Vus).To(Equal(4))
+
+		_, err = client.PatchStatus(ctx, "default", "pod-1", api.StatusAttributes{Vus: intPtr(11)})
+		Expect(err).To(MatchError(ContainSubstring("above vu cap")))
+
+		status, err = client.PatchStatus(ctx, "default", "pod-1", api.StatusAttributes{VusMax: intPtr(20), Vus: intPtr(15)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*status.Vus).To(Equal(15))
+
+		metrics, err := client.Metrics(ctx, "default", "pod-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metrics).To(ContainElement(And(
+			HaveField("ID", "vus"),
+			HaveField("Attributes.Sample", HaveKeyWithValue("value", 15.0)),
+		)))
+	})
+
+	It("reports the groups and their checks", func() {
+		instance.AddGroup("login", api.Check{Name: "status is 200", Passes: 9, Fails: 1})
+
+		groups, err := client.Groups(ctx, "default", "pod-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(HaveLen(2))
+		Expect(groups[0].Relationships.Groups.Data).To(ConsistOf(api.ResourceRef{Type: "groups", ID: groups[1].ID}))
+		Expect(groups[1].Attributes.Path).To(Equal("::login"))
+		Expect(groups[1].Attributes.Checks).To(ConsistOf(HaveField("Fails", int64(1))))
+	})
+
+	It("injects failures", func() {
+		instance.Fail(Failure{Method: http.MethodPatch, Code: http.StatusServiceUnavailable, Times: 1})
+
+		_, err := client.PatchStatus(ctx, "default", "pod-1", api.StatusAttributes{Paused: boolPtr(false)})
+		Expect(err).To(BeAssignableToTypeOf(&k6.StatusError{}))
+		Expect(err.(*k6.StatusError).Code).To(Equal(http.StatusServiceUnavailable))
+
+		_, err = client.PatchStatus(ctx, "default", "pod-1", api.StatusAttributes{Paused: boolPtr(false)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instance.Requests()).To(HaveLen(2))
+	})
+
+	It("injects latency", func() {
+		instance.SetLatency(time.Second)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := client.Status(timeoutCtx, "default", "pod-1")
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("reports missing pods", func() {
+		_, err := client.Status(ctx, "default", "pod-2")
+		Expect(err).To(MatchError(&k6.StatusError{Code: http.StatusNotFound}))
+	})
+})
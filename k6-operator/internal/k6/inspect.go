@@ -0,0 +1,35 @@
+package k6
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/api"
+)
+
+// InspectCommand returns the command printing the execution requirements of a script.
+func InspectCommand(script string) []string {
+	return []string{"k6", "inspect", "--execution-requirements", script}
+}
+
+// ParseExecutionRequirements extracts the execution requirements from the output of `k6 inspect`. The script
+// might log while being initialized, so everything before the JSON document is ignored.
+func ParseExecutionRequirements(output []byte) (*api.ExecutionRequirements, error) {
+	for offset := 0; offset < len(output); {
+		if output[offset] == '{' {
+			reqs := &api.ExecutionRequirements{}
+			if err := json.NewDecoder(bytes.NewReader(output[offset:])).Decode(reqs); err == nil {
+				return reqs, nil
+			}
+		}
+
+		next := bytes.IndexByte(output[offset:], '\n')
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+	}
+
+	return nil, fmt.Errorf("no execution requirements found in the k6 inspect output")
+}
@@ -0,0 +1,47 @@
+package k6
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/k6/api"
+)
+
+var _ = Describe("ParseExecutionRequirements", func() {
+	It("parses the output of k6 inspect", func() {
+		reqs, err := ParseExecutionRequirements([]byte(`{
+  "paused": null,
+  "scenarios": {
+    "browse": {
+      "executor": "ramping-vus",
+      "startTime": "10s",
+      "stages": [{"duration": "1m", "target": 100}]
+    }
+  },
+  "totalDuration": "1m40s",
+  "maxVUs": 100
+}
+`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reqs.MaxVUs).To(Equal(int64(100)))
+		Expect(reqs.TotalDuration).To(Equal("1m40s"))
+		Expect(reqs.Scenarios).To(Equal(map[string]api.Scenario{
+			"browse": {Executor: "ramping-vus", StartTime: "10s"},
+		}))
+	})
+
+	It("ignores what the script logs before the output", func() {
+		reqs, err := ParseExecutionRequirements([]byte("time=\"2024-01-01T00:00:00Z\" level=info msg={not json} source=console\n" +
+			`{"maxVUs": 20, "totalDuration": "30s"}`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reqs.MaxVUs).To(Equal(int64(20)))
+	})
+
+	It("fails without any output", func() {
+		_, err := ParseExecutionRequirements([]byte("level=error msg=\"could not initialize\"\n"))
+
+		Expect(err).To(MatchError(ContainSubstring("no execution requirements")))
+	})
+})
@@ -0,0 +1,14 @@
+package k6
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestK6(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "k6 Suite")
+}
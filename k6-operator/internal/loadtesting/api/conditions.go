@@ -0,0 +1,92 @@
+package api
+
+import (
+	"time"
+)
+
+// Condition types reported on a job, in the order the phases happen.
+const (
+	// CONDITION_SIZED is true once the worker sizing was decided from the execution requirements of the script.
+	CONDITION_SIZED string = "Sized"
+	// CONDITION_SCRIPT_FETCHED is true once all the worker pods cloned the test script.
+	CONDITION_SCRIPT_FETCHED string = "ScriptFetched"
+	// CONDITION_PODS_SCHEDULED is true once all the worker pods were assigned to a node.
+	CONDITION_PODS_SCHEDULED string = "PodsScheduled"
+	// CONDITION_PODS_READY is true once all the worker pods are ready to start testing.
+	CONDITION_PODS_READY string = "PodsReady"
+	// CONDITION_IGNITED is true once all the worker pods were un-paused.
+	CONDITION_IGNITED string = "Ignited"
+	// CONDITION_START_CONFIRMED is true once all the locations of the test run confirmed they started.
+	CONDITION_START_CONFIRMED string = "StartConfirmed"
+	// CONDITION_METRICS_FLUSHING is true while k6 is done but metrics are still being sent.
+	CONDITION_METRICS_FLUSHING string = "MetricsFlushing"
+	// CONDITION_THRESHOLDS_PASSED is true if k6 reported no failed threshold on any worker pod.
+	CONDITION_THRESHOLDS_PASSED string = "ThresholdsPassed"
+	// CONDITION_DISRUPTED is true if any worker pod was evicted by a node disruption (drain, preemption, ...).
+	CONDITION_DISRUPTED string = "Disrupted"
+	// CONDITION_DEGRADED is true if the test completed with lost segments, within the failure tolerance.
+	CONDITION_DEGRADED string = "Degraded"
+)
+
+// Condition statuses.
+const (
+	CONDITION_TRUE    string = "True"
+	CONDITION_FALSE   string = "False"
+	CONDITION_UNKNOWN string = "Unknown"
+)
+
+// Condition is the state of a phase of the job, modeled after the Kubernetes conditions.
+type Condition struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	// Reason is a CamelCase word describing why the condition is in its status.
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	// LastTransitionTime is when the status last changed.
+	LastTransitionTime time.Time `json:"last_transition_time"`
+}
+
+// GetCondition returns the condition of the given type, or nil if it's not set.
+func (o *Job) GetCondition(conditionType string) *Condition {
+	for idx := range o.Conditions {
+		if o.Conditions[idx].Type == conditionType {
+			return &o.Conditions[idx]
+		}
+	}
+	return nil
+}
+
+// SetCondition sets a condition, keeping its transition time if the status didn't change. It returns true if
+// anything changed.
+func (o *Job) SetCondition(conditionType string, status string, reason string, message string) bool {
+	condition := o.GetCondition(conditionType)
+	if condition == nil {
+		o.Conditions = append(o.Conditions, Condition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: time.Now().UTC().Truncate(time.Second),
+		})
+		return true
+	}
+
+	if condition.Status == status && condition.Reason == reason && condition.Message == message {
+		return false
+	}
+
+	if condition.Status != status {
+		condition.LastTransitionTime = time.Now().UTC().Truncate(time.Second)
+	}
+	condition.Status = status
+	condition.Reason = reason
+	condition.Message = message
+
+	return true
+}
+
+// IsConditionTrue returns true if the condition of the given type is set and true.
+func (o *Job) IsConditionTrue(conditionType string) bool {
+	condition := o.GetCondition(conditionType)
+	return condition != nil && condition.Status == CONDITION_TRUE
+}
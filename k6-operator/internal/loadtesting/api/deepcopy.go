@@ -0,0 +1,84 @@
+package api
+
+import (
+	"maps"
+	"slices"
+)
+
+// DeepCopy returns a copy of the job sharing no memory with it, so it can be read by other goroutines while the
+// original one is updated.
+func (o *Job) DeepCopy() *Job {
+	if o == nil {
+		return nil
+	}
+
+	out := *o
+	out.AssignedSegments = slices.Clone(o.AssignedSegments)
+	out.StartOffset = o.StartOffset.DeepCopy()
+	out.IgnitionStagger = o.IgnitionStagger.DeepCopy()
+	out.TestRun = *o.TestRun.DeepCopy()
+	out.Ignition = o.Ignition.DeepCopy()
+	out.Sizing = o.Sizing.DeepCopy()
+	out.LostSegments = slices.Clone(o.LostSegments)
+	out.DisruptedSegments = slices.Clone(o.DisruptedSegments)
+	out.Conditions = slices.Clone(o.Conditions)
+	return &out
+}
+
+// DeepCopy returns a copy of the test run sharing no memory with it.
+func (t *TestRun) DeepCopy() *TestRun {
+	if t == nil {
+		return nil
+	}
+
+	out := *t
+	out.EnvVars = maps.Clone(t.EnvVars)
+	out.Labels = maps.Clone(t.Labels)
+	out.Segments = slices.Clone(t.Segments)
+	if t.StartTestAt != nil {
+		startTestAt := *t.StartTestAt
+		out.StartTestAt = &startTestAt
+	}
+	out.ResourceCPU = t.ResourceCPU.DeepCopy()
+	out.ResourceMemory = t.ResourceMemory.DeepCopy()
+	out.NodeSelector = maps.Clone(t.NodeSelector)
+	out.JobDeadline = t.JobDeadline.DeepCopy()
+	out.LateStartTolerance = t.LateStartTolerance.DeepCopy()
+	out.StartConfirmationWindow = t.StartConfirmationWindow.DeepCopy()
+	out.LastStartOffset = t.LastStartOffset.DeepCopy()
+	return &out
+}
+
+// DeepCopy returns a copy of the ignition report sharing no memory with it.
+func (o *Ignition) DeepCopy() *Ignition {
+	if o == nil {
+		return nil
+	}
+
+	out := *o
+	out.Pods = slices.Clone(o.Pods)
+	return &out
+}
+
+// DeepCopy returns a copy of the sizing sharing no memory with it.
+func (o *Sizing) DeepCopy() *Sizing {
+	if o == nil {
+		return nil
+	}
+
+	out := *o
+	out.Scenarios = maps.Clone(o.Scenarios)
+	out.CPU = o.CPU.DeepCopy()
+	out.Memory = o.Memory.DeepCopy()
+	return &out
+}
+
+// DeepCopy returns a copy of the duration.
+func (d *Duration) DeepCopy() *Duration {
+	if d == nil {
+		return nil
+	}
+
+	out := *d
+	return &out
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/runtime"
+)
+
+// RunLocation is a location a test run is executed in, with the state of its job.
+type RunLocation struct {
+	Location          string      `json:"location"`
+	Workers           int32       `json:"num_workers"`
+	OnlineWorkers     int32       `json:"online_workers,omitempty"`
+	Status            string      `json:"status,omitempty"`
+	StatusDescription string      `json:"status_description,omitempty"`
+	Conditions        []Condition `json:"conditions,omitempty"`
+}
+
+// IsFinished returns true if the job of the location won't change anymore.
+func (l *RunLocation) IsFinished() bool {
+	switch l.Status {
+	case STATUS_COMPLETED, STATUS_FAILED, STATUS_CANCELED:
+		return true
+	}
+	return false
+}
+
+// Run is a test run, as created and managed by users through the API. The jobs are the parts of a run executed in
+// each location.
+type Run struct {
+	Name            string            `json:"name,omitempty"`
+	Target          string            `json:"target"`
+	SourceRepo      string            `json:"source_repo,omitempty"`
+	SourceRef       string            `json:"source_ref,omitempty"`
+	SourceScript    string            `json:"source_script,omitempty"`
+	ResourcesCPU    string            `json:"resources_cpu,omitempty"`
+	ResourcesMemory string            `json:"resources_memory,omitempty"`
+	DedicatedNodes  *bool             `json:"dedicated_nodes,omitempty"`
+	NodeSelector    string            `json:"node_selector,omitempty"`
+	JobDeadline     string            `json:"job_deadline,omitempty"`
+	TestOutput      string            `json:"test_output,omitempty"`
+	EnvVars         map[string]string `json:"env_vars,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Locations       []RunLocation     `json:"locations"`
+
+	// The fields below are set by the API.
+	Draft       bool       `json:"draft,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	GrafanaURL  string     `json:"grafana_url,omitempty"`
+}
+
+type RunList struct {
+	Items []Run `json:"items"`
+}
+
+func (o *Run) GetName() string {
+	return o.Name
+}
+
+func (o *Run) ToK8SResource() client.Object {
+	panic("Should not be used, so not implemented!")
+}
+
+// Status summarizes the statuses of all the locations. A run failed or was canceled as soon as one of its locations
+// did, and it's in the least advanced status of its locations otherwise.
+func (o *Run) Status() string {
+	if o.Draft {
+		return "draft"
+	}
+
+	order := []string{STATUS_PENDING, STATUS_QUEUED, STATUS_READY, STATUS_RUNNING, STATUS_COMPLETED}
+	status := STATUS_COMPLETED
+	for _, location := range o.Locations {
+		switch location.Status {
+		case STATUS_FAILED, STATUS_CANCELED:
+			return location.Status
+		}
+		for _, s := range order {
+			if s == status {
+				break
+			}
+			if s == location.Status {
+				status = s
+				break
+			}
+		}
+	}
+
+	return status
+}
+
+// IsFinished returns true if the jobs of all the locations are finished.
+func (o *Run) IsFinished() bool {
+	if o.Draft {
+		return false
+	}
+	for _, location := range o.Locations {
+		if !location.IsFinished() {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *RunList) ToK8SResource() client.Object {
+	panic("Should not be used, so not implemented!")
+}
+
+func (o *RunList) GetItem() runtime.Object {
+	return &Run{}
+}
+
+func (o *RunList) SetItems(items []runtime.Object) {
+	o.Items = make([]Run, len(items))
+	for i, item := range items {
+		o.Items[i] = *(item.(*Run))
+	}
+}
+
+// Location is a testing location, where a k6 operator is running.
+type Location struct {
+	Name        string     `json:"name"`
+	DisplayName string     `json:"display_name"`
+	LastPing    *time.Time `json:"last_ping"`
+	// Online is true if the operator checked in recently.
+	Online bool `json:"online"`
+}
+
+type LocationList struct {
+	Items []Location `json:"items"`
+}
+
+func (o *Location) GetName() string {
+	return o.Name
+}
+
+func (o *Location) ToK8SResource() client.Object {
+	panic("Should not be used, so not implemented!")
+}
+
+func (o *LocationList) GetItem() runtime.Object {
+	return &Location{}
+}
+
+func (o *LocationList) SetItems(items []runtime.Object) {
+	o.Items = make([]Location, len(items))
+	for i, item := range items {
+		o.Items[i] = *(item.(*Location))
+	}
+}
+
+func init() {
+	runtime.Schema.Register(&Run{}, &RunList{}, "testruns")
+	runtime.Schema.Register(&Location{}, &LocationList{}, "locations")
+}
@@ -3,11 +3,13 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"sort"
 	"strings"
 	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
@@ -25,7 +27,32 @@ const (
 	STATUS_FAILED    string = "failed"
 )
 
-type Ping struct{}
+const (
+	// LATE_START_IMMEDIATELY starts the test as soon as possible, regardless of the lateness.
+	LATE_START_IMMEDIATELY string = "start"
+	// LATE_START_FAIL fails the test if the lateness exceeds the tolerance.
+	LATE_START_FAIL string = "fail"
+
+	// There is no policy skipping ahead: k6 can't seek into its scenarios, its REST API only pauses, resumes or
+	// stops them.
+)
+
+const (
+	// SIZING_OFF uses the worker count and resources set on the test run.
+	SIZING_OFF string = "off"
+	// SIZING_RECOMMEND inspects the script and reports the recommended worker count and resources, without
+	// applying them.
+	SIZING_RECOMMEND string = "recommend"
+	// SIZING_AUTO inspects the script and applies the recommended worker count and resources. The API applies the
+	// worker count, so the segments are recomputed before any location creates its worker pods.
+	SIZING_AUTO string = "auto"
+)
+
+// Ping is the heartbeat sent periodically by the operator.
+type Ping struct {
+	// ClockSkew is the measured offset between the webapp clock and the operator clock.
+	ClockSkew *Duration `json:"clock_skew,omitempty"`
+}
 type PingList struct {
 	Items []Ping `json:"items"`
 }
@@ -61,6 +88,85 @@ type TestRun struct {
 	NodeSelector   NodeSelector      `json:"node_selector"`
 	JobDeadline    *Duration         `json:"job_deadline"`
 	DedicatedNodes bool              `json:"dedicated_nodes"`
+	// LateStartPolicy decides what happens when a location is ready after StartTestAt.
+	LateStartPolicy string `json:"late_start_policy"`
+	// LateStartTolerance is the lateness accepted before the LateStartPolicy applies.
+	LateStartTolerance *Duration `json:"late_start_tolerance"`
+	// AbortIfIncomplete stops the test in all the locations if any of them didn't confirm it started within the
+	// StartConfirmationWindow, so partial results aren't mistaken for complete ones.
+	AbortIfIncomplete bool `json:"abort_if_incomplete"`
+	// StartConfirmationWindow is the time, after StartTestAt, all the locations have to confirm they started.
+	StartConfirmationWindow *Duration `json:"start_confirmation_window"`
+	// Confirmed is set by the API once all the locations confirmed they started.
+	Confirmed bool `json:"confirmed"`
+	// LastStartOffset is the time, after StartTestAt, the last location is done starting its worker pods, including
+	// its start offset and ignition stagger.
+	LastStartOffset *Duration `json:"last_start_offset"`
+	// Sizing decides if the worker count and resources are derived from the execution requirements of the script.
+	Sizing string `json:"sizing"`
+	// Sized is set by the API once all the locations reported their sizing.
+	Sized bool `json:"sized"`
+	// FailureTolerance is the number (ex. 2) or percentage (ex. 10%) of the segments of a location which can fail
+	// while the test still completes.
+	FailureTolerance string `json:"failure_tolerance"`
+}
+
+// GetLateStartPolicy returns the late start policy, defaulting to LATE_START_IMMEDIATELY.
+func (t *TestRun) GetLateStartPolicy() string {
+	switch t.LateStartPolicy {
+	case LATE_START_FAIL:
+		return t.LateStartPolicy
+	default:
+		return LATE_START_IMMEDIATELY
+	}
+}
+
+// GetLateStartTolerance returns the late start tolerance, defaulting to 5 seconds.
+func (t *TestRun) GetLateStartTolerance() time.Duration {
+	if t.LateStartTolerance == nil {
+		return 5 * time.Second
+	}
+	return t.LateStartTolerance.Duration
+}
+
+// GetStartConfirmationWindow returns the start confirmation window, defaulting to 30 seconds.
+func (t *TestRun) GetStartConfirmationWindow() time.Duration {
+	if t.StartConfirmationWindow == nil || t.StartConfirmationWindow.Duration <= 0 {
+		return 30 * time.Second
+	}
+	return t.StartConfirmationWindow.Duration
+}
+
+// GetLastStartOffset returns the time, after StartTestAt, the last location is done starting its worker pods.
+func (t *TestRun) GetLastStartOffset() time.Duration {
+	if t.LastStartOffset == nil || t.LastStartOffset.Duration < 0 {
+		return 0
+	}
+	return t.LastStartOffset.Duration
+}
+
+// GetSizing returns the sizing mode, defaulting to SIZING_OFF.
+func (t *TestRun) GetSizing() string {
+	switch t.Sizing {
+	case SIZING_RECOMMEND, SIZING_AUTO:
+		return t.Sizing
+	default:
+		return SIZING_OFF
+	}
+}
+
+// GetAllowedFailures returns how many of the given number of segments can fail while the test still completes.
+// Percentages are rounded down and at least one segment has to succeed.
+func (t *TestRun) GetAllowedFailures(segments int) int {
+	if t.FailureTolerance == "" || segments < 2 {
+		return 0
+	}
+	tolerance := intstr.Parse(strings.TrimSpace(t.FailureTolerance))
+	allowed, err := intstr.GetScaledValueFromIntOrPercent(&tolerance, segments, false)
+	if err != nil || allowed < 0 {
+		return 0
+	}
+	return min(allowed, segments-1)
 }
 
 type TestOutputConfig struct {
@@ -76,6 +182,38 @@ type Segment struct {
 	Segment string `json:"segment"`
 }
 
+// PodIgnition records when a worker pod was un-paused.
+type PodIgnition struct {
+	Pod       string    `json:"pod"`
+	IgnitedAt time.Time `json:"ignited_at"`
+}
+
+// Ignition reports how the worker pods of a location were started.
+type Ignition struct {
+	Pods []PodIgnition `json:"pods"`
+	// Spread is the time between the first and the last pod being un-paused.
+	Spread Duration `json:"spread"`
+	// Lateness is how late, compared to StartTestAt, the ignition began.
+	Lateness Duration `json:"lateness"`
+}
+
+// Sizing is the worker sizing of a location, decided from the execution requirements of the script.
+type Sizing struct {
+	// MaxVUs and TotalDuration are reported by k6 for the whole test run.
+	MaxVUs        int64    `json:"max_vus"`
+	TotalDuration Duration `json:"total_duration"`
+	// Scenarios maps the scenarios of the script to their executor.
+	Scenarios map[string]string `json:"scenarios,omitempty"`
+	// VUs is the maximum number of VUs run in this location.
+	VUs int64 `json:"vus"`
+	// Workers, CPU and Memory are the recommended worker count and per-worker resources.
+	Workers int32             `json:"workers"`
+	CPU     resource.Quantity `json:"cpu"`
+	Memory  resource.Quantity `json:"memory"`
+	// Applied is true if the recommendation is used for the worker pods.
+	Applied bool `json:"applied"`
+}
+
 // Job is a struct that represents a job to be executed by the worker.
 // It is exposed by the web application trough the workers API.
 type Job struct {
@@ -87,8 +225,34 @@ type Job struct {
 	Workers           int32            `json:"num_workers"`
 	OnlineWorkers     int32            `json:"online_workers"`
 	AssignedSegments  []Segment        `json:"assigned_segments"`
+	StartOffset       *Duration        `json:"start_offset"`
+	IgnitionStagger   *Duration        `json:"ignition_stagger"`
 	TestRun           TestRun          `json:"test_run"`
 	OutputConfig      TestOutputConfig `json:"output_config"`
+	Ignition          *Ignition        `json:"ignition,omitempty"`
+	Sizing            *Sizing          `json:"sizing,omitempty"`
+	LostSegments      []Segment        `json:"lost_segments,omitempty"`
+	DisruptedSegments []Segment        `json:"disrupted_segments,omitempty"`
+	Conditions        []Condition      `json:"conditions,omitempty"`
+	UpdatedAt         string           `json:"updated_at,omitempty"`
+
+	// LocationName is the location the job was retrieved for. It's set by the client.
+	LocationName string `json:"-"`
+	// Version is the ETag of the job, if the API sent one. It's set by the client.
+	Version string `json:"-"`
+}
+
+// JobStatus is the body sent to the status endpoint of a job. UpdatedAt is the version of the job the status was
+// decided on, the API rejects the update if the job changed since.
+type JobStatus struct {
+	Status            string      `json:"status"`
+	StatusDescription string      `json:"status_description"`
+	Ignition          *Ignition   `json:"ignition,omitempty"`
+	Sizing            *Sizing     `json:"sizing,omitempty"`
+	LostSegments      []Segment   `json:"lost_segments,omitempty"`
+	DisruptedSegments []Segment   `json:"disrupted_segments,omitempty"`
+	Conditions        []Condition `json:"conditions,omitempty"`
+	UpdatedAt         string      `json:"updated_at,omitempty"`
 }
 
 type JobList struct {
@@ -114,10 +278,121 @@ func (o *Job) GetName() string {
 	return o.Name
 }
 
+func (o *Job) SetLocationName(name string) {
+	o.LocationName = name
+}
+
+func (o *Job) GetVersion() string {
+	return o.Version
+}
+
+func (o *Job) SetVersion(version string) {
+	o.Version = version
+}
+
+func (o *Job) StatusPayload() interface{} {
+	return &JobStatus{
+		Status:            o.Status,
+		StatusDescription: o.StatusDescription,
+		Ignition:          o.Ignition,
+		Sizing:            o.Sizing,
+		LostSegments:      o.LostSegments,
+		DisruptedSegments: o.DisruptedSegments,
+		Conditions:        o.Conditions,
+		UpdatedAt:         o.UpdatedAt,
+	}
+}
+
+// GetStartOffset returns the delay of the start of this location, relative to the start of the test run.
+func (o *Job) GetStartOffset() time.Duration {
+	if o.StartOffset == nil || o.StartOffset.Duration < 0 {
+		return 0
+	}
+	return o.StartOffset.Duration
+}
+
+// GetIgnitionStagger returns the duration the start of the worker pods of this location is spread over.
+func (o *Job) GetIgnitionStagger() time.Duration {
+	if o.IgnitionStagger == nil || o.IgnitionStagger.Duration < 0 {
+		return 0
+	}
+	return o.IgnitionStagger.Duration
+}
+
+// StartAt returns when the test starts in this location, in the API clock. The test run must have a start time.
+func (o *Job) StartAt() time.Time {
+	return o.TestRun.StartTestAt.Add(o.GetStartOffset())
+}
+
+// ConfirmationDeadline returns when all the locations have to confirm they started, in the API clock. The
+// confirmation window starts once the last location is done starting its worker pods. The test run must have a
+// start time.
+func (o *Job) ConfirmationDeadline() time.Time {
+	lastStartOffset := max(o.TestRun.GetLastStartOffset(), o.GetStartOffset()+o.GetIgnitionStagger())
+	return o.TestRun.StartTestAt.Add(lastStartOffset + o.TestRun.GetStartConfirmationWindow())
+}
+
 func (o *Job) GetNamespace() string {
+	switch options.NamespaceMode {
+	case options.NamespaceModePerTestRun:
+		return namespaceName(o.namespacePrefix(), o.Name)
+	case options.NamespaceModePerTeam:
+		if team := o.TestRun.Labels[options.NamespaceTeamLabel]; team != "" {
+			return namespaceName(o.namespacePrefix(), team)
+		}
+		// the jobs of the test run in the other locations sharing the namespace would be named the same
+		if o.sharesLocationNamespace() {
+			return namespaceName(o.namespacePrefix(), o.Name)
+		}
+	}
+
+	if namespace, found := options.LocationNamespaces[o.LocationName]; found && namespace != "" {
+		return namespace
+	}
+
 	return options.JobNamespace
 }
 
+// namespacePrefix returns the prefix of the namespaces created for the job. It includes the location when the
+// operator serves multiple locations, as the jobs of a test run are named the same in all its locations.
+func (o *Job) namespacePrefix() string {
+	if len(options.LocationNamespaces) > 1 && o.LocationName != "" {
+		return options.NamespacePrefix + "-" + o.LocationName
+	}
+	return options.NamespacePrefix
+}
+
+// sharesLocationNamespace returns true if the namespace of the location of the job is used by other locations too.
+func (o *Job) sharesLocationNamespace() bool {
+	namespace := options.LocationNamespaces[o.LocationName]
+	for location, other := range options.LocationNamespaces {
+		if location != o.LocationName && other == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceName builds a valid namespace name (RFC 1123 label) out of a prefix and a name.
+func namespaceName(prefix string, name string) string {
+	ns := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, strings.Trim(prefix+"-"+name, "-"))
+
+	if len(ns) > 63 {
+		ns = ns[:63]
+	}
+
+	return strings.Trim(ns, "-")
+}
+
 func (o *Job) ToK8SResource() client.Object {
 	obj := batchv1.Job{}
 
@@ -158,6 +433,17 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 
 type NodeSelector map[string]string
 
+// MarshalJSON converts the NodeSelector back to the label=value space separated format of the API.
+func (o NodeSelector) MarshalJSON() ([]byte, error) {
+	pairs := make([]string, 0, len(o))
+	for key, value := range o {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return json.Marshal(strings.Join(pairs, " "))
+}
+
 // UnmarshalJSON is a custom unmarshaler for the NodeSelector type.
 // It converts from label=value space separated string to a map of label -> value
 func (o *NodeSelector) UnmarshalJSON(data []byte) error {
@@ -0,0 +1,55 @@
+package api
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/options"
+)
+
+var _ = Describe("Job.GetNamespace", func() {
+	BeforeEach(func() {
+		DeferCleanup(func(mode string, namespaces map[string]string) {
+			options.NamespaceMode = mode
+			options.LocationNamespaces = namespaces
+		}, options.NamespaceMode, options.LocationNamespaces)
+	})
+
+	east := &Job{Name: "run", LocationName: "east"}
+	west := &Job{Name: "run", LocationName: "west"}
+
+	It("creates the namespaces of a test run per location", func() {
+		options.NamespaceMode = options.NamespaceModePerTestRun
+		options.LocationNamespaces = map[string]string{"east": "default", "west": "default"}
+		Expect(east.GetNamespace()).To(Equal("orderly-ape-east-run"))
+		Expect(west.GetNamespace()).To(Equal("orderly-ape-west-run"))
+
+		options.LocationNamespaces = map[string]string{"east": "default"}
+		Expect(east.GetNamespace()).To(Equal("orderly-ape-run"))
+	})
+
+	It("creates the namespaces of a team per location", func() {
+		options.NamespaceMode = options.NamespaceModePerTeam
+		options.LocationNamespaces = map[string]string{"east": "default", "west": "default"}
+		team := map[string]string{options.NamespaceTeamLabel: "checkout"}
+		Expect((&Job{Name: "run", LocationName: "east", TestRun: TestRun{Labels: team}}).GetNamespace()).
+			To(Equal("orderly-ape-east-checkout"))
+	})
+
+	It("keeps the jobs of test runs without a team apart when locations share a namespace", func() {
+		options.NamespaceMode = options.NamespaceModePerTeam
+		options.LocationNamespaces = map[string]string{"east": "default", "west": "default"}
+		Expect(east.GetNamespace()).To(Equal("orderly-ape-east-run"))
+		Expect(west.GetNamespace()).To(Equal("orderly-ape-west-run"))
+
+		options.LocationNamespaces = map[string]string{"east": "loadtesting-east", "west": "loadtesting-west"}
+		Expect(east.GetNamespace()).To(Equal("loadtesting-east"))
+	})
+
+	It("uses the namespace of the location in the shared mode", func() {
+		options.NamespaceMode = options.NamespaceModeShared
+		options.LocationNamespaces = map[string]string{"east": "loadtesting-east", "west": "loadtesting-west"}
+		Expect(east.GetNamespace()).To(Equal("loadtesting-east"))
+		Expect(west.GetNamespace()).To(Equal("loadtesting-west"))
+	})
+})
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+var _ = Describe("UncachedClient.Action", func() {
+	var (
+		server  *httptest.Server
+		handler http.HandlerFunc
+		c       *UncachedClient
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r)
+		}))
+
+		var err error
+		c, err = NewUncachedClient(WithBaseURL(server.URL), WithRetries(0, 0))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("posts to the action of the object and decodes the response", func() {
+		var body map[string]string
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/testruns/run-1/cancel" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name": "run-1", "target": "https://example.com", "locations": [{"location": "eu", "num_workers": 2, "status": "canceled"}]}`))
+		}
+
+		run := &api.Run{Name: "run-1"}
+		Expect(c.Action(context.Background(), run, "cancel", map[string]string{"message": "stop"})).To(Succeed())
+		Expect(body).To(HaveKeyWithValue("message", "stop"))
+		Expect(run.Target).To(Equal("https://example.com"))
+		Expect(run.Status()).To(Equal(api.STATUS_CANCELED))
+		Expect(run.IsFinished()).To(BeTrue())
+	})
+
+	It("reports errors", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		}
+
+		err := c.Action(context.Background(), &api.Run{Name: "run-1"}, "start", nil)
+		Expect(IsConflict(err)).To(BeTrue())
+	})
+})
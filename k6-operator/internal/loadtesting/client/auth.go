@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"os"
+
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Authenticator configures how the client authenticates against the API.
+type Authenticator interface {
+	Apply(c *resty.Client) error
+}
+
+// BasicAuth authenticates using HTTP basic auth.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a *BasicAuth) Apply(c *resty.Client) error {
+	c.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// BearerToken authenticates using a static bearer token.
+type BearerToken struct {
+	Token string
+}
+
+func (a *BearerToken) Apply(c *resty.Client) error {
+	if a.Token == "" {
+		return errors.New("bearer token is empty")
+	}
+
+	c.SetAuthToken(a.Token)
+	return nil
+}
+
+// ClientCertificate authenticates using a TLS client certificate. CAFile, if set, is a PEM bundle used to verify
+// the API server certificate.
+type ClientCertificate struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+func (a *ClientCertificate) Apply(c *resty.Client) error {
+	transport, err := c.Transport()
+	if err != nil {
+		return err
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+
+	if a.CertFile != "" || a.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(a.CertFile, a.KeyFile)
+		if err != nil {
+			return err
+		}
+		transport.TLSClientConfig.Certificates = append(transport.TLSClientConfig.Certificates, cert)
+	}
+
+	if a.CAFile != "" {
+		ca, err := os.ReadFile(a.CAFile)
+		if err != nil {
+			return err
+		}
+		c.SetRootCertificateFromString(string(ca))
+	}
+
+	return nil
+}
+
+// OAuth2ClientCredentials authenticates using the OAuth2 client credentials flow. Tokens are requested from
+// TokenURL and refreshed automatically before they expire.
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+func (a *OAuth2ClientCredentials) Apply(c *resty.Client) error {
+	if a.TokenURL == "" || a.ClientID == "" {
+		return errors.New("oauth2 token url and client id are required")
+	}
+
+	config := &clientcredentials.Config{
+		ClientID:     a.ClientID,
+		ClientSecret: a.ClientSecret,
+		TokenURL:     a.TokenURL,
+		Scopes:       a.Scopes,
+	}
+
+	// The token endpoint is reached with the same HTTP client, so it uses the same TLS settings
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, c.GetClient())
+	tokens := config.TokenSource(ctx)
+
+	c.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		token, err := tokens.Token()
+		if err != nil {
+			return err
+		}
+		req.SetAuthToken(token.AccessToken)
+		req.SetAuthScheme(token.Type())
+		return nil
+	})
+
+	return nil
+}
@@ -12,4 +12,6 @@ type Client interface {
 	Watch(obj runtime.Object) (<-chan runtime.Object, error)
 	Create(ctx context.Context, obj runtime.Object) error
 	Update(ctx context.Context, obj runtime.Object) error
+	// UpdateStatus only sends the status of obj, if it supports it, otherwise it's the same as Update.
+	UpdateStatus(ctx context.Context, obj runtime.Object) error
 }
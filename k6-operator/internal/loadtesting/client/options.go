@@ -14,7 +14,19 @@ type options struct {
 
 	Region string
 
+	Authenticators []Authenticator
+
 	CacheRefreshInterval time.Duration
+	ClockSkewSmoothing   float64
+	Retries              int
+	RetryWaitTime        time.Duration
+	RetryMaxWaitTime     time.Duration
+	QPS                  float32
+	Burst                int
+	BreakerThreshold     int
+	BreakerCooldown      time.Duration
+	WatchTransport       string
+	WatchResyncInterval  time.Duration
 	Logger               logr.Logger
 	StopCh               chan struct{}
 
@@ -27,6 +39,16 @@ func defaultOptions() *options {
 		Username:             "admin",
 		Password:             "admin",
 		CacheRefreshInterval: 5 * time.Second,
+		ClockSkewSmoothing:   0.2,
+		Retries:              3,
+		RetryWaitTime:        500 * time.Millisecond,
+		RetryMaxWaitTime:     30 * time.Second,
+		QPS:                  10,
+		Burst:                20,
+		BreakerThreshold:     5,
+		BreakerCooldown:      30 * time.Second,
+		WatchTransport:       WatchAuto,
+		WatchResyncInterval:  time.Minute,
 	}
 }
 
@@ -47,6 +69,54 @@ func WithCacheRefreshInterval(seconds int) Option {
 	}
 }
 
+// WithClockSkewSmoothing sets the weight given to every new clock skew sample.
+func WithClockSkewSmoothing(smoothing float64) Option {
+	return func(args *options) {
+		args.ClockSkewSmoothing = smoothing
+	}
+}
+
+// WithRetries sets how many times idempotent requests are retried, and the initial wait between attempts.
+// The wait grows exponentially, with jitter.
+func WithRetries(retries int, waitTime time.Duration) Option {
+	return func(args *options) {
+		args.Retries = retries
+		args.RetryWaitTime = waitTime
+	}
+}
+
+// WithRateLimit sets the maximum number of requests per second sent to the API. Zero disables the rate limit.
+func WithRateLimit(qps float32, burst int) Option {
+	return func(args *options) {
+		args.QPS = qps
+		args.Burst = burst
+	}
+}
+
+// WithCircuitBreaker sets after how many consecutive failures the circuit breaker opens, and how long it stays
+// open. A zero threshold disables the circuit breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(args *options) {
+		args.BreakerThreshold = threshold
+		args.BreakerCooldown = cooldown
+	}
+}
+
+// WithWatchTransport sets the transport used to watch for changes: auto, poll, long-poll or sse. Streaming
+// transports fall back to polling when the API doesn't support them.
+func WithWatchTransport(transport string) Option {
+	return func(args *options) {
+		args.WatchTransport = transport
+	}
+}
+
+// WithWatchResyncInterval sets how often all the objects are listed again when using a streaming transport.
+func WithWatchResyncInterval(interval time.Duration) Option {
+	return func(args *options) {
+		args.WatchResyncInterval = interval
+	}
+}
+
 // WithBaseURL to set the base URL
 func WithBaseURL(baseURL string) Option {
 	return func(args *options) {
@@ -68,6 +138,14 @@ func WithPassword(password string) Option {
 	}
 }
 
+// WithAuthenticator adds an authenticator. Authenticators are applied in order and replace the basic auth
+// credentials.
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(args *options) {
+		args.Authenticators = append(args.Authenticators, authenticator)
+	}
+}
+
 // WithRegion sets the region.
 func WithRegion(region string) Option {
 	return func(args *options) {
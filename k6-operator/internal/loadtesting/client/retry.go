@@ -0,0 +1,63 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// isRetryableStatus returns true for the status codes signaling a transient error.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryCondition retries idempotent requests which failed with a network error or a transient status code.
+func retryCondition(resp *resty.Response, err error) bool {
+	// errors raised before sending the request, like an open circuit breaker, come without a response
+	if resp == nil || resp.Request == nil {
+		return false
+	}
+
+	switch resp.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+	default:
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	return isRetryableStatus(resp.StatusCode())
+}
+
+// retryAfter honors the Retry-After header sent with 429 and 503 responses. It returns 0 to fall back to the
+// jittered exponential backoff.
+func retryAfter(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+	if resp == nil {
+		return 0, nil
+	}
+	if resp.StatusCode() != http.StatusTooManyRequests && resp.StatusCode() != http.StatusServiceUnavailable {
+		return 0, nil
+	}
+
+	value := resp.Header().Get("Retry-After")
+	if value == "" {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if at, err := http.ParseTime(value); err == nil && time.Until(at) > 0 {
+		return time.Until(at), nil
+	}
+
+	return 0, nil
+}
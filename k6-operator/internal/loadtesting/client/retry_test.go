@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+var _ = Describe("UncachedClient resilience", func() {
+	var (
+		server   *httptest.Server
+		requests atomic.Int32
+		failures int32
+	)
+
+	BeforeEach(func() {
+		requests.Store(0)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) <= failures {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name": "test"}`))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func(opts ...Option) *UncachedClient {
+		c, err := NewUncachedClient(append([]Option{
+			WithBaseURL(server.URL),
+			WithRegion("test"),
+			WithRetries(2, 10*time.Millisecond),
+		}, opts...)...)
+		Expect(err).NotTo(HaveOccurred())
+		return c
+	}
+
+	It("retries idempotent requests, honoring Retry-After", func() {
+		failures = 1
+		start := time.Now()
+
+		Expect(newClient().Get(context.Background(), "test", &api.Job{})).To(Succeed())
+		Expect(requests.Load()).To(BeEquivalentTo(2))
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+	})
+
+	It("doesn't retry non idempotent requests", func() {
+		failures = 1
+
+		err := newClient().Create(context.Background(), &api.Ping{})
+		Expect(err).To(HaveOccurred())
+		Expect(IsRetryable(err)).To(BeTrue())
+		Expect(requests.Load()).To(BeEquivalentTo(1))
+	})
+
+	It("opens the circuit breaker after consecutive failures", func() {
+		failures = 100
+		c := newClient(WithRetries(0, 0), WithCircuitBreaker(2, time.Minute))
+
+		for i := 0; i < 2; i++ {
+			Expect(c.Get(context.Background(), "test", &api.Job{})).NotTo(Succeed())
+		}
+		Expect(c.Ready(nil)).To(MatchError(ErrCircuitOpen))
+
+		Expect(c.Get(context.Background(), "test", &api.Job{})).To(MatchError(ErrCircuitOpen))
+		Expect(requests.Load()).To(BeEquivalentTo(2))
+	})
+})
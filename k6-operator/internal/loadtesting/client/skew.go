@@ -0,0 +1,89 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// maxSkewSampleRTT is the round-trip time above which a response is not used to estimate the clock skew,
+// as the uncertainty of the measurement would be bigger than the skew we are trying to measure.
+const maxSkewSampleRTT = 2 * time.Second
+
+// ClockSource is implemented by clients that are able to estimate the offset between the local clock and
+// the clock of the remote.
+type ClockSource interface {
+	// ClockOffset returns the estimated remote time minus the local time, and whether an estimate is available.
+	ClockOffset() (time.Duration, bool)
+}
+
+// ClockSkew estimates the offset between the local clock and the clock of a remote HTTP server, using the
+// `Date` header of the responses and their round-trip time. The samples are smoothed using an exponentially
+// weighted moving average.
+type ClockSkew struct {
+	mu        sync.RWMutex
+	smoothing float64
+	offset    time.Duration
+	samples   int
+}
+
+// NewClockSkew instantiate a clock skew estimator. smoothing is the weight (between 0 and 1) given to every
+// new sample.
+func NewClockSkew(smoothing float64) *ClockSkew {
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 1
+	}
+
+	return &ClockSkew{
+		smoothing: smoothing,
+	}
+}
+
+// Observe records a sample. sent and received are local timestamps of the request, remote is the time reported
+// by the server.
+func (s *ClockSkew) Observe(sent, received, remote time.Time) {
+	rtt := received.Sub(sent)
+	if rtt < 0 || rtt > maxSkewSampleRTT {
+		return
+	}
+
+	// The `Date` header has a one second resolution, so the remote time is anywhere within that second.
+	// We assume the server generated it half way through the request.
+	offset := remote.Add(500 * time.Millisecond).Sub(sent.Add(rtt / 2))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.samples == 0 {
+		s.offset = offset
+	} else {
+		s.offset += time.Duration(s.smoothing * float64(offset-s.offset))
+	}
+	s.samples++
+}
+
+// ClockOffset returns the estimated remote time minus the local time, and whether an estimate is available.
+func (s *ClockSkew) ClockOffset() (time.Duration, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.offset, s.samples > 0
+}
+
+// observeResponse is a resty middleware that feeds every response into the estimator.
+func (s *ClockSkew) observeResponse(_ *resty.Client, resp *resty.Response) error {
+	date := resp.Header().Get("Date")
+	if date == "" || resp.Request == nil {
+		return nil
+	}
+
+	remote, err := http.ParseTime(date)
+	if err != nil {
+		return nil
+	}
+
+	s.Observe(resp.Request.Time, resp.ReceivedAt(), remote)
+	return nil
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClockSkew", func() {
+	sent := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	It("has no estimate before observing any response", func() {
+		_, known := NewClockSkew(0.5).ClockOffset()
+		Expect(known).To(BeFalse())
+	})
+
+	It("estimates the offset from the first sample", func() {
+		skew := NewClockSkew(0.5)
+		// the server is 3s ahead and answered at the middle of a 200ms round-trip
+		skew.Observe(sent, sent.Add(200*time.Millisecond), sent.Add(3*time.Second))
+
+		offset, known := skew.ClockOffset()
+		Expect(known).To(BeTrue())
+		Expect(offset).To(Equal(3*time.Second + 400*time.Millisecond))
+	})
+
+	It("smooths subsequent samples", func() {
+		skew := NewClockSkew(0.5)
+		skew.Observe(sent, sent, sent.Add(-500*time.Millisecond))
+		skew.Observe(sent, sent, sent.Add(1500*time.Millisecond))
+
+		offset, _ := skew.ClockOffset()
+		Expect(offset).To(Equal(time.Second))
+	})
+
+	It("ignores samples with a high round-trip time", func() {
+		skew := NewClockSkew(0.5)
+		skew.Observe(sent, sent.Add(10*time.Second), sent)
+
+		_, known := skew.ClockOffset()
+		Expect(known).To(BeFalse())
+	})
+})
@@ -1,7 +1,13 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
 
 	"github.com/go-resty/resty/v2"
 )
@@ -37,9 +43,41 @@ func IsNotFound(err error) bool {
 	return false
 }
 
+// IsConflict returns true if the update was rejected because the object changed since it was retrieved.
+func IsConflict(err error) bool {
+	if status, ok := err.(*StatusError); ok {
+		return status.Code == 409 || status.Code == 412
+	}
+	return false
+}
+
 func IgnoreNotFound(err error) error {
 	if IsNotFound(err) {
 		return nil
 	}
 	return err
 }
+
+// IsRetryable returns true if err is a transient error, so the request can be sent again later: a network error or
+// timeout, an unavailable API, or a 5xx or 429 response. Canceled requests and invalid responses aren't, nor are
+// conflicts, as sending the same update again would conflict again.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if status, ok := err.(*StatusError); ok {
+		return status.Code >= 500 || status.Code == http.StatusTooManyRequests
+	}
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// the http client wraps every error, network ones or not, in a url.Error
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+var _ = Describe("IsRetryable", func() {
+	var (
+		server  *httptest.Server
+		handler http.HandlerFunc
+		c       *UncachedClient
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r)
+		}))
+
+		var err error
+		c, err = NewUncachedClient(WithBaseURL(server.URL), WithRegion("test"), WithRetries(0, 0))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func(ctx context.Context) error {
+		return c.Get(ctx, "job-1", &api.Job{})
+	}
+
+	DescribeTable("status codes",
+		func(code int, retryable bool) {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(code)
+			}
+			Expect(IsRetryable(get(context.Background()))).To(Equal(retryable))
+		},
+		Entry("500", http.StatusInternalServerError, true),
+		Entry("502", http.StatusBadGateway, true),
+		Entry("503", http.StatusServiceUnavailable, true),
+		Entry("429", http.StatusTooManyRequests, true),
+		Entry("409", http.StatusConflict, false),
+		Entry("400", http.StatusBadRequest, false),
+		Entry("403", http.StatusForbidden, false),
+		Entry("404", http.StatusNotFound, false),
+		Entry("412", http.StatusPreconditionFailed, false),
+	)
+
+	It("retries network errors", func() {
+		server.Close()
+		Expect(IsRetryable(get(context.Background()))).To(BeTrue())
+	})
+
+	It("retries timeouts", func() {
+		block := make(chan struct{})
+		defer close(block)
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-block:
+			case <-r.Context().Done():
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		Expect(IsRetryable(get(ctx))).To(BeTrue())
+	})
+
+	It("retries while the API is unavailable", func() {
+		Expect(IsRetryable(fmt.Errorf("loadtesting API is unavailable: %w", ErrCircuitOpen))).To(BeTrue())
+	})
+
+	It("doesn't retry invalid responses", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name": `))
+		}
+		err := get(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(IsRetryable(err)).To(BeFalse())
+	})
+
+	It("doesn't retry canceled requests", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := get(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(IsRetryable(err)).To(BeFalse())
+		Expect(IsRetryable(context.Canceled)).To(BeFalse())
+	})
+})
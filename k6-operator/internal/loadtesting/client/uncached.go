@@ -4,21 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/client-go/util/flowcontrol"
 
-	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
 	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/runtime"
 )
 
 // UncachedClient represents an instance of Client, without an internal cache.
 type UncachedClient struct {
-	client *resty.Client
-
+	// mu guards client, which Reconfigure replaces
+	mu      sync.RWMutex
+	client  *resty.Client
+	skew    *ClockSkew
+	limiter flowcontrol.RateLimiter
+	breaker *CircuitBreaker
+
+	// statusUnsupported is set once the API is found to have no status endpoint
+	statusUnsupported atomic.Bool
+
+	// options are the ones the client was created with, and never change. The base URL and the credentials in use
+	// are the ones of client.
 	options
 }
 
@@ -31,37 +44,151 @@ func NewUncachedClient(opts ...Option) (*UncachedClient, error) {
 		opt(options)
 	}
 
-	c := resty.New().SetDisableWarn(true)
-	c = c.SetBaseURL(options.URL).SetBasicAuth(options.Username, options.Password)
-
-	return &UncachedClient{
-		client:  c,
+	client := &UncachedClient{
+		skew:    NewClockSkew(options.ClockSkewSmoothing),
+		breaker: NewCircuitBreaker(options.BreakerThreshold, options.BreakerCooldown),
 		options: *options,
-	}, nil
+	}
+	if options.QPS > 0 {
+		client.limiter = flowcontrol.NewTokenBucketRateLimiter(options.QPS, options.Burst)
+	}
+
+	c, err := client.newRestClient(options)
+	if err != nil {
+		return nil, err
+	}
+	client.client = c
+
+	return client, nil
+}
+
+// newRestClient creates the HTTP client used to reach the API. The clock skew estimator, the rate limiter and
+// the circuit breaker are shared by all the HTTP clients, so they survive reconfigurations.
+func (c *UncachedClient) newRestClient(options *options) (*resty.Client, error) {
+	rc := resty.New().SetDisableWarn(true)
+	rc = rc.SetBaseURL(options.URL)
+
+	rc = rc.SetRetryCount(options.Retries).
+		SetRetryWaitTime(options.RetryWaitTime).
+		SetRetryMaxWaitTime(options.RetryMaxWaitTime).
+		SetRetryAfter(retryAfter).
+		AddRetryCondition(retryCondition)
+
+	if c.limiter != nil {
+		limiter := c.limiter
+		rc = rc.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+			return limiter.Wait(req.Context())
+		})
+	}
+
+	authenticators := options.Authenticators
+	if len(authenticators) == 0 {
+		authenticators = []Authenticator{&BasicAuth{
+			Username: options.Username,
+			Password: options.Password,
+		}}
+	}
+	for _, authenticator := range authenticators {
+		if err := authenticator.Apply(rc); err != nil {
+			return nil, err
+		}
+	}
+
+	rc = rc.OnAfterResponse(c.skew.observeResponse)
+
+	return rc, nil
+}
+
+// Reconfigure atomically replaces the base URL and the credentials of the client. In-flight requests complete
+// with the previous settings. The other options, like the region or the logger, can't be changed.
+func (c *UncachedClient) Reconfigure(opts ...Option) error {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	client, err := c.newRestClient(options)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.client = client
+
+	return nil
+}
+
+// rest returns the HTTP client currently in use.
+func (c *UncachedClient) rest() *resty.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.client
+}
+
+// execute sends a request, unless the circuit breaker is open, and records its result.
+func (c *UncachedClient) execute(req *resty.Request, method, endpoint string) (*resty.Response, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := req.Execute(method, endpoint)
+	switch {
+	case req.Context().Err() != nil:
+		c.breaker.Cancel()
+	case err != nil:
+		c.breaker.Record(false)
+	default:
+		c.breaker.Record(resp.StatusCode() < 500 && resp.StatusCode() != http.StatusTooManyRequests)
+	}
+
+	return resp, err
+}
+
+// Ready fails while the circuit breaker is open. It's designed to be used as a readiness check.
+func (c *UncachedClient) Ready(_ *http.Request) error {
+	if c.breaker.IsOpen() {
+		return fmt.Errorf("loadtesting API is unavailable for %s: %w", c.Region, ErrCircuitOpen)
+	}
+	return nil
+}
+
+// ClockOffset returns the estimated offset between the remote clock and the local one.
+func (c *UncachedClient) ClockOffset() (time.Duration, bool) {
+	return c.skew.ClockOffset()
 }
 
 // List retrieve a list of objects from the remote and store them in obj.
 func (c *UncachedClient) List(ctx context.Context, obj runtime.ObjectList) error {
+	_, _, err := c.list(ctx, obj, nil)
+	return err
+}
+
+// list retrieves a list of objects, passing query to the remote, and returns the items and the response.
+func (c *UncachedClient) list(ctx context.Context, obj runtime.ObjectList, query map[string]string) ([]runtime.Object, *resty.Response, error) {
 	endpoint, err := runtime.Schema.GetEndpointForList(obj)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	sl := reflect.SliceOf(runtime.Schema.GetObjType(obj))
 	store := reflect.MakeSlice(sl, 0, 0).Interface()
 
-	resp, respErr := c.client.R().
+	req := c.rest().R().
 		SetResult(store).
 		SetPathParams(map[string]string{"locationName": c.Region}).
-		SetContext(ctx).
-		Get(endpoint)
+		SetQueryParams(query).
+		SetContext(ctx)
+	resp, respErr := c.execute(req, resty.MethodGet, endpoint)
 
 	if respErr != nil {
-		return respErr
+		return nil, nil, respErr
 	}
 
 	if resp.IsError() {
-		return NewStatusError(resp)
+		return nil, nil, NewStatusError(resp)
 	}
 
 	result := resp.Result()
@@ -69,7 +196,7 @@ func (c *UncachedClient) List(ctx context.Context, obj runtime.ObjectList) error
 
 	// Check if v is a pointer and points to a slice
 	if resultReflect.Kind() != reflect.Ptr || resultReflect.Elem().Kind() != reflect.Slice {
-		return errors.New("Result is not a slice")
+		return nil, nil, errors.New("Result is not a slice")
 	}
 
 	// Now you can iterate over the slice
@@ -79,12 +206,16 @@ func (c *UncachedClient) List(ctx context.Context, obj runtime.ObjectList) error
 		ok := false
 		items[i], ok = slice.Index(i).Addr().Interface().(runtime.Object)
 		if !ok {
-			return errors.New("result is not of type Object")
+			return nil, nil, errors.New("result is not of type Object")
 		}
 	}
 
+	for _, item := range items {
+		c.setLocation(item)
+	}
+
 	obj.SetItems(items)
-	return nil
+	return items, resp, nil
 }
 
 // Get retrieves an object by it's name, from the remote.
@@ -104,11 +235,11 @@ func (c *UncachedClient) Get(ctx context.Context, id string, obj runtime.Object)
 	}
 
 	realType := reflect.Indirect(reflect.ValueOf(obj))
-	resp, respErr := c.client.R().
+	req := c.rest().R().
 		SetResult(realType.Interface()).
 		SetPathParams(map[string]string{"locationName": c.Region}).
-		SetContext(ctx).
-		Get(endpoint)
+		SetContext(ctx)
+	resp, respErr := c.execute(req, resty.MethodGet, endpoint)
 
 	if respErr != nil {
 		return respErr
@@ -122,36 +253,10 @@ func (c *UncachedClient) Get(ctx context.Context, id string, obj runtime.Object)
 	outVal := reflect.ValueOf(obj)
 	reflect.Indirect(outVal).Set(newObj)
 
-	return nil
-}
-
-func (c *UncachedClient) Watch(obj runtime.Object) (<-chan runtime.Object, error) {
-	ch := make(chan runtime.Object)
-
-	c.Logger.Info("start watching", "type", runtime.RealTypeOf(obj))
-
-	go func() {
-		t := time.NewTicker(5 * time.Second)
-		for {
-			select {
-			case <-c.StopCh:
-				return
-			case <-t.C:
-				objList := api.JobList{}
-
-				if err := c.List(context.Background(), &objList); err != nil {
-					c.Logger.Error(err, "can't list objects")
-					time.Sleep(8 * time.Second)
-				}
+	c.setLocation(obj)
+	c.setVersion(obj, resp)
 
-				for idx := range objList.Items {
-					ch <- &objList.Items[idx]
-				}
-			}
-		}
-	}()
-
-	return ch, nil
+	return nil
 }
 
 func (c *UncachedClient) Create(ctx context.Context, obj runtime.Object) error {
@@ -166,12 +271,12 @@ func (c *UncachedClient) Create(ctx context.Context, obj runtime.Object) error {
 	}
 
 	realType := reflect.Indirect(reflect.ValueOf(obj))
-	resp, respErr := c.client.R().
+	req := c.rest().R().
 		SetResult(realType.Interface()).
 		SetBody(obj).
 		SetPathParams(map[string]string{"locationName": c.Region}).
-		SetContext(ctx).
-		Post(endpoint)
+		SetContext(ctx)
+	resp, respErr := c.execute(req, resty.MethodPost, endpoint)
 	if respErr != nil {
 		return respErr
 	}
@@ -183,6 +288,7 @@ func (c *UncachedClient) Create(ctx context.Context, obj runtime.Object) error {
 	newObj := reflect.Indirect(reflect.ValueOf(resp.Result()))
 	outVal := reflect.ValueOf(obj)
 	reflect.Indirect(outVal).Set(newObj)
+	c.setLocation(obj)
 	return nil
 }
 
@@ -201,12 +307,13 @@ func (c *UncachedClient) Update(ctx context.Context, obj runtime.Object) error {
 	}
 
 	realType := reflect.Indirect(reflect.ValueOf(obj))
-	resp, respErr := c.client.R().
+	req := c.rest().R().
 		SetResult(realType.Interface()).
 		SetBody(obj).
 		SetPathParams(map[string]string{"locationName": c.Region}).
-		SetContext(ctx).
-		Put(endpoint)
+		SetContext(ctx)
+	setPrecondition(req, obj)
+	resp, respErr := c.execute(req, resty.MethodPut, endpoint)
 	if respErr != nil {
 		return respErr
 	}
@@ -218,5 +325,119 @@ func (c *UncachedClient) Update(ctx context.Context, obj runtime.Object) error {
 	newObj := reflect.Indirect(reflect.ValueOf(resp.Result()))
 	outVal := reflect.ValueOf(obj)
 	reflect.Indirect(outVal).Set(newObj)
+	c.setLocation(obj)
+	c.setVersion(obj, resp)
 	return nil
 }
+
+// Action posts to a custom action of an object, like `testruns/<name>/start`. The object is updated with the
+// response, if the API sends one.
+func (c *UncachedClient) Action(ctx context.Context, obj runtime.Object, action string, body interface{}) error {
+	_, err := conversion.EnforcePtr(obj)
+	if err != nil {
+		return err
+	}
+	endpoint, err := runtime.Schema.GetEndpointForObj(obj)
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(endpoint, "%s") {
+		endpoint = fmt.Sprintf(endpoint, obj.GetName())
+	}
+
+	realType := reflect.Indirect(reflect.ValueOf(obj))
+	req := c.rest().R().
+		SetResult(realType.Interface()).
+		SetPathParams(map[string]string{"locationName": c.Region}).
+		SetContext(ctx)
+	if body != nil {
+		req.SetBody(body)
+	}
+	resp, respErr := c.execute(req, resty.MethodPost, endpoint+"/"+action)
+	if respErr != nil {
+		return respErr
+	}
+
+	if resp.StatusCode() != 200 && resp.StatusCode() != 202 && resp.StatusCode() != 204 {
+		return NewStatusError(resp)
+	}
+
+	if resp.StatusCode() == 200 && len(resp.Body()) > 0 {
+		newObj := reflect.Indirect(reflect.ValueOf(resp.Result()))
+		reflect.Indirect(reflect.ValueOf(obj)).Set(newObj)
+		c.setLocation(obj)
+	}
+	return nil
+}
+
+// UpdateStatus sends the status of obj to its status endpoint, so the rest of the object is never overwritten.
+// If the API doesn't have a status endpoint, the whole object is updated instead.
+func (c *UncachedClient) UpdateStatus(ctx context.Context, obj runtime.Object) error {
+	statused, ok := obj.(runtime.Statused)
+	if !ok || c.statusUnsupported.Load() {
+		return c.Update(ctx, obj)
+	}
+
+	endpoint, err := runtime.Schema.GetEndpointForObj(obj)
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(endpoint, "%s") {
+		endpoint = fmt.Sprintf(endpoint, obj.GetName())
+	}
+
+	realType := reflect.Indirect(reflect.ValueOf(obj))
+	req := c.rest().R().
+		SetResult(realType.Interface()).
+		SetBody(statused.StatusPayload()).
+		SetPathParams(map[string]string{"locationName": c.Region}).
+		SetContext(ctx)
+	setPrecondition(req, obj)
+	resp, respErr := c.execute(req, resty.MethodPatch, endpoint+"/status")
+	if respErr != nil {
+		return respErr
+	}
+
+	switch resp.StatusCode() {
+	case 200:
+	case 405:
+		c.Logger.Info("the API has no status endpoint, updating whole objects")
+		c.statusUnsupported.Store(true)
+		return c.Update(ctx, obj)
+	case 404:
+		// either the object or the status endpoint is missing
+		return c.Update(ctx, obj)
+	default:
+		return NewStatusError(resp)
+	}
+
+	newObj := reflect.Indirect(reflect.ValueOf(resp.Result()))
+	outVal := reflect.ValueOf(obj)
+	reflect.Indirect(outVal).Set(newObj)
+	c.setLocation(obj)
+	c.setVersion(obj, resp)
+	return nil
+}
+
+// setPrecondition makes the request fail if obj changed since it was retrieved.
+func setPrecondition(req *resty.Request, obj runtime.Object) {
+	if versioned, ok := obj.(runtime.Versioned); ok && versioned.GetVersion() != "" {
+		req.SetHeader("If-Match", versioned.GetVersion())
+	}
+}
+
+// setVersion records on the object the version sent by the API.
+func (c *UncachedClient) setVersion(obj runtime.Object, resp *resty.Response) {
+	if versioned, ok := obj.(runtime.Versioned); ok {
+		versioned.SetVersion(resp.Header().Get("ETag"))
+	}
+}
+
+// setLocation records on the object the location it belongs to.
+func (c *UncachedClient) setLocation(obj runtime.Object) {
+	if located, ok := obj.(runtime.Located); ok {
+		located.SetLocationName(c.Region)
+	}
+}
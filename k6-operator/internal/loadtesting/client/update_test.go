@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+var _ = Describe("UncachedClient.UpdateStatus", func() {
+	var (
+		server  *httptest.Server
+		handler http.HandlerFunc
+		c       *UncachedClient
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r)
+		}))
+
+		var err error
+		c, err = NewUncachedClient(WithBaseURL(server.URL), WithRegion("test"), WithRetries(0, 0))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("only sends the status, with the job version", func() {
+		var body map[string]interface{}
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPatch || r.URL.Path != "/workers/test/jobs/job-1/status" || r.Header.Get("If-Match") != `"v1"` {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"v2"`)
+			_, _ = w.Write([]byte(`{"name": "job-1", "status": "running"}`))
+		}
+
+		job := &api.Job{Name: "job-1", Status: "running", UpdatedAt: "2024-06-01T12:00:00Z", Version: `"v1"`}
+		Expect(c.UpdateStatus(context.Background(), job)).To(Succeed())
+		Expect(body).To(HaveKeyWithValue("updated_at", "2024-06-01T12:00:00Z"))
+		Expect(body).NotTo(HaveKey("test_run"))
+		Expect(job.Version).To(Equal(`"v2"`))
+	})
+
+	It("reports conflicts", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+
+		err := c.UpdateStatus(context.Background(), &api.Job{Name: "job-1", Version: `"v1"`})
+		Expect(IsConflict(err)).To(BeTrue())
+		Expect(IsRetryable(err)).To(BeFalse())
+	})
+
+	It("falls back to updating the whole job", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name": "job-1", "status": "running"}`))
+		}
+
+		Expect(c.UpdateStatus(context.Background(), &api.Job{Name: "job-1"})).To(Succeed())
+		Expect(c.statusUnsupported.Load()).To(BeTrue())
+	})
+})
+
+// webappJobs serves jobs the way the webapp does: every response carries the version of the job as an ETag, and
+// updates sent with a stale If-Match are rejected with 412.
+type webappJobs struct {
+	mu       sync.Mutex
+	job      map[string]interface{}
+	requests []string
+}
+
+func (s *webappJobs) etag() string {
+	data, _ := json.Marshal(s.job)
+	return fmt.Sprintf(`"%x"`, sha256.Sum256(data))
+}
+
+func (s *webappJobs) change(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.job[key] = value
+}
+
+func (s *webappJobs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/workers/test/jobs/job-1":
+	case r.Method == http.MethodPatch && r.URL.Path == "/workers/test/jobs/job-1/status":
+		if match := r.Header.Get("If-Match"); match != "" && match != s.etag() {
+			w.Header().Set("ETag", s.etag())
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		status := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&status)
+		for _, key := range []string{"status", "status_description", "conditions", "ignition", "sizing"} {
+			if value, found := status[key]; found {
+				s.job[key] = value
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", s.etag())
+	_ = json.NewEncoder(w).Encode(s.job)
+}
+
+var _ = Describe("UncachedClient against the webapp", func() {
+	var (
+		webapp *webappJobs
+		server *httptest.Server
+		c      *UncachedClient
+		job    *api.Job
+	)
+
+	BeforeEach(func() {
+		webapp = &webappJobs{job: map[string]interface{}{"name": "job-1", "status": "queued"}}
+		server = httptest.NewServer(webapp)
+
+		var err error
+		c, err = NewUncachedClient(WithBaseURL(server.URL), WithRegion("test"), WithRetries(0, 0))
+		Expect(err).NotTo(HaveOccurred())
+
+		job = &api.Job{}
+		Expect(c.Get(context.Background(), "job-1", job)).To(Succeed())
+		Expect(job.Version).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("updates the status in a single request, with the version of the job", func() {
+		job.Status = api.STATUS_RUNNING
+		Expect(c.UpdateStatus(context.Background(), job)).To(Succeed())
+		Expect(webapp.requests).To(Equal([]string{"GET /workers/test/jobs/job-1", "PATCH /workers/test/jobs/job-1/status"}))
+		Expect(webapp.job).To(HaveKeyWithValue("status", "running"))
+
+		// the version sent back is used for the next update
+		job.StatusDescription = "Running"
+		Expect(c.UpdateStatus(context.Background(), job)).To(Succeed())
+		Expect(webapp.job).To(HaveKeyWithValue("status_description", "Running"))
+	})
+
+	It("reports the job was changed since it was retrieved, without overwriting it", func() {
+		webapp.change("status", "canceled")
+
+		job.Status = api.STATUS_RUNNING
+		err := c.UpdateStatus(context.Background(), job)
+		Expect(IsConflict(err)).To(BeTrue())
+		Expect(webapp.requests).To(HaveLen(2))
+		Expect(webapp.job).To(HaveKeyWithValue("status", "canceled"))
+		Expect(c.statusUnsupported.Load()).To(BeFalse())
+	})
+})
+
+var _ = Describe("UncachedClient.Reconfigure", func() {
+	// Run with -race: the requests in flight read the options while the client is reconfigured.
+	It("swaps the API while requests are sent", func() {
+		servers := make([]*httptest.Server, 2)
+		for idx := range servers {
+			name := fmt.Sprintf("job-%d", idx)
+			servers[idx] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprintf(w, `{"name": %q}`, name)
+			}))
+			DeferCleanup(servers[idx].Close)
+		}
+
+		c, err := NewUncachedClient(WithBaseURL(servers[0].URL), WithRegion("test"), WithRetries(0, 0), WithRateLimit(0, 0))
+		Expect(err).NotTo(HaveOccurred())
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer GinkgoRecover()
+			defer wg.Done()
+			for idx := 0; idx < 20; idx++ {
+				job := &api.Job{}
+				Expect(c.Get(context.Background(), "job", job)).To(Succeed())
+				Expect(job.LocationName).To(Equal("test"))
+			}
+		}()
+		Expect(c.Reconfigure(WithBaseURL(servers[1].URL), WithRegion("test"))).To(Succeed())
+		wg.Wait()
+
+		job := &api.Job{}
+		Expect(c.Get(context.Background(), "job", job)).To(Succeed())
+		Expect(job.Name).To(Equal("job-1"))
+	})
+})
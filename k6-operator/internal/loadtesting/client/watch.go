@@ -0,0 +1,290 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/runtime"
+)
+
+// Transports used to watch for changes.
+const (
+	// WatchAuto uses the best transport advertised by the API, falling back to polling.
+	WatchAuto = "auto"
+	// WatchPoll lists all the objects periodically.
+	WatchPoll = "poll"
+	// WatchLongPoll keeps a list request open until something changes after a cursor.
+	WatchLongPoll = "long-poll"
+	// WatchSSE streams changes as server-sent events.
+	WatchSSE = "sse"
+)
+
+const (
+	// watchTransportsHeader is sent by the API with list responses. It's a comma separated list of the
+	// streaming transports it supports.
+	watchTransportsHeader = "X-Watch-Transports"
+	// watchCursorHeader is sent by the API with list and long-poll responses. It's the position in the change
+	// feed to resume from.
+	watchCursorHeader = "X-Watch-Cursor"
+
+	pollInterval     = 5 * time.Second
+	longPollTimeout  = 30 * time.Second
+	sseIdleTimeout   = 60 * time.Second
+	watchRetryPeriod = 8 * time.Second
+)
+
+// errResync is returned by a streaming transport when the API asks for a full list.
+var errResync = errors.New("resync requested")
+
+// Watch sends all the objects of the same type as obj, then their changes, to the returned channel. The
+// transport is selected with WithWatchTransport. Objects are listed again every resync interval, and every time
+// a stream is interrupted.
+func (c *UncachedClient) Watch(obj runtime.Object) (<-chan runtime.Object, error) {
+	kind := runtime.RealTypeOf(obj).String()
+	if _, err := runtime.Schema.NewList(kind); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan runtime.Object)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if c.StopCh != nil {
+			<-c.StopCh
+			cancel()
+		}
+	}()
+
+	c.Logger.Info("start watching", "type", kind, "transport", c.WatchTransport)
+	go c.watch(ctx, kind, ch)
+
+	return ch, nil
+}
+
+func (c *UncachedClient) watch(ctx context.Context, kind string, ch chan<- runtime.Object) {
+	lastTransport := ""
+	for ctx.Err() == nil {
+		// a full list catches up with all the changes and tells which transports are supported
+		list, _ := runtime.Schema.NewList(kind)
+		items, resp, err := c.list(ctx, list, nil)
+		if err != nil {
+			// failed requests are already retried, and the circuit breaker backs off while the API is down
+			c.Logger.Error(err, "can't list objects")
+			sleep(ctx, pollInterval)
+			continue
+		}
+		if !send(ctx, items, ch) {
+			return
+		}
+
+		transport := c.selectTransport(resp.Header().Get(watchTransportsHeader))
+		if transport != lastTransport {
+			c.Logger.Info("watching for changes", "type", kind, "transport", transport)
+			lastTransport = transport
+		}
+
+		cursor := resp.Header().Get(watchCursorHeader)
+		resync, stop := context.WithTimeout(ctx, c.WatchResyncInterval)
+		switch transport {
+		case WatchSSE:
+			err = c.watchEvents(resync, kind, cursor, ch)
+		case WatchLongPoll:
+			err = c.watchLongPoll(resync, kind, cursor, ch)
+		default:
+			sleep(ctx, pollInterval)
+		}
+		stop()
+
+		if err != nil && ctx.Err() == nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, errResync) {
+			c.Logger.Error(err, "watch interrupted", "transport", transport)
+			sleep(ctx, watchRetryPeriod)
+		}
+	}
+}
+
+// selectTransport picks the configured transport, if the API supports it.
+func (c *UncachedClient) selectTransport(advertised string) string {
+	supported := map[string]bool{}
+	for _, transport := range strings.Split(advertised, ",") {
+		supported[strings.TrimSpace(transport)] = true
+	}
+
+	switch c.WatchTransport {
+	case WatchSSE, WatchLongPoll:
+		if supported[c.WatchTransport] {
+			return c.WatchTransport
+		}
+	case WatchAuto:
+		if supported[WatchSSE] {
+			return WatchSSE
+		}
+		if supported[WatchLongPoll] {
+			return WatchLongPoll
+		}
+	}
+
+	return WatchPoll
+}
+
+// watchLongPoll repeatedly asks for the changes after cursor, until the context is done or an error occurs.
+func (c *UncachedClient) watchLongPoll(ctx context.Context, kind string, cursor string, ch chan<- runtime.Object) error {
+	for {
+		if cursor == "" {
+			return errors.New("the API didn't return a watch cursor")
+		}
+
+		list, _ := runtime.Schema.NewList(kind)
+		reqCtx, cancel := context.WithTimeout(ctx, longPollTimeout+10*time.Second)
+		items, resp, err := c.list(reqCtx, list, map[string]string{
+			"watch":   WatchLongPoll,
+			"cursor":  cursor,
+			"timeout": strconv.Itoa(int(longPollTimeout.Seconds())),
+		})
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		if !send(ctx, items, ch) {
+			return ctx.Err()
+		}
+		cursor = resp.Header().Get(watchCursorHeader)
+	}
+}
+
+// watchEvents reads the server-sent events stream, starting after cursor, until the context is done or the
+// stream is closed.
+func (c *UncachedClient) watchEvents(ctx context.Context, kind string, cursor string, ch chan<- runtime.Object) error {
+	list, _ := runtime.Schema.NewList(kind)
+	endpoint, err := runtime.Schema.GetEndpointForList(list)
+	if err != nil {
+		return err
+	}
+
+	// the server sends heartbeats, so a silent stream is a dead one
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := time.AfterFunc(sseIdleTimeout, cancel)
+	defer idle.Stop()
+
+	req := c.rest().R().
+		SetDoNotParseResponse(true).
+		SetHeader("Accept", "text/event-stream").
+		SetPathParams(map[string]string{"locationName": c.Region}).
+		SetQueryParam("watch", WatchSSE).
+		SetContext(streamCtx)
+	if cursor != "" {
+		req.SetHeader("Last-Event-ID", cursor)
+	}
+
+	resp, err := c.execute(req, resty.MethodGet, endpoint)
+	if err != nil {
+		return err
+	}
+	body := resp.RawBody()
+	defer body.Close()
+
+	if resp.IsError() {
+		return NewStatusError(resp)
+	}
+	if contentType := resp.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		return fmt.Errorf("unexpected content type for event stream: %s", contentType)
+	}
+
+	objType := runtime.Schema.GetObjType(list)
+	event, data := "", []string{}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		idle.Reset(sseIdleTimeout)
+		line := scanner.Text()
+
+		// a blank line dispatches the event
+		if line == "" {
+			if err := c.dispatchEvent(ctx, event, data, objType, ch); err != nil {
+				return err
+			}
+			event, data = "", data[:0]
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+		// event ids are ignored, as streams are resumed from the cursor of a full list
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// dispatchEvent decodes the object sent with an event and passes it on.
+func (c *UncachedClient) dispatchEvent(ctx context.Context, event string, data []string, objType reflect.Type, ch chan<- runtime.Object) error {
+	switch event {
+	case "", "message", "change":
+	case "resync":
+		return errResync
+	default:
+		// heartbeats and unknown events
+		return nil
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	obj, ok := reflect.New(objType).Interface().(runtime.Object)
+	if !ok {
+		return errors.New("result is not of type Object")
+	}
+	if err := json.Unmarshal([]byte(strings.Join(data, "\n")), obj); err != nil {
+		return err
+	}
+	c.setLocation(obj)
+
+	select {
+	case ch <- obj:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send passes all the items on. It returns false if the context is done.
+func send(ctx context.Context, items []runtime.Object, ch chan<- runtime.Object) bool {
+	for _, obj := range items {
+		select {
+		case ch <- obj:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return true
+}
+
+// sleep waits for d, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/runtime"
+)
+
+var _ = Describe("UncachedClient.Watch", func() {
+	var (
+		server     *httptest.Server
+		stop       chan struct{}
+		transports string
+	)
+
+	BeforeEach(func() {
+		stop = make(chan struct{})
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("watch") == WatchSSE {
+				if r.Header.Get("Last-Event-ID") != "42" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, ": comment\n\nevent: heartbeat\n\nevent: change\ndata: {\"name\": \"streamed\"}\n\n")
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+				return
+			}
+
+			w.Header().Set(watchTransportsHeader, transports)
+			w.Header().Set(watchCursorHeader, "42")
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[{"name": "listed"}]`)
+		}))
+	})
+
+	AfterEach(func() {
+		close(stop)
+		server.Close()
+	})
+
+	watch := func() <-chan runtime.Object {
+		c, err := NewUncachedClient(WithBaseURL(server.URL), WithRegion("test"))
+		Expect(err).NotTo(HaveOccurred())
+		c.StopCh = stop
+
+		ch, err := c.Watch(&api.Job{})
+		Expect(err).NotTo(HaveOccurred())
+		return ch
+	}
+
+	name := func(obj runtime.Object) string {
+		return obj.GetName()
+	}
+
+	It("streams changes when the API supports server-sent events", func() {
+		transports = "long-poll, sse"
+		ch := watch()
+
+		Eventually(ch).Should(Receive(WithTransform(name, Equal("listed"))))
+		Eventually(ch).Should(Receive(WithTransform(name, Equal("streamed"))))
+	})
+
+	It("falls back to polling", func() {
+		transports = ""
+		ch := watch()
+
+		Eventually(ch).Should(Receive(WithTransform(name, Equal("listed"))))
+		Consistently(ch, 2*time.Second).ShouldNot(Receive())
+	})
+})
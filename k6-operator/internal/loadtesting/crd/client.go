@@ -0,0 +1,222 @@
+// Package crd drives the operator from TestRun resources instead of the webapp API. It implements the same client
+// as the API, so the controller reconciles jobs the same way, whatever their source.
+package crd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/api/v1alpha1"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/runtime"
+)
+
+var log = ctrl.Log.WithName("loadtesting-crd")
+
+// StartDelay is the time between the worker pods being ready and the test starting, when the TestRun doesn't
+// set a start time.
+var StartDelay = 10 * time.Second
+
+// Client reads jobs from the TestRun resources of a namespace and writes their status back.
+type Client struct {
+	client    ctrlclient.Client
+	reader    ctrlclient.Reader
+	informers cache.Informers
+	location  string
+	namespace string
+}
+
+var _ client.Client = &Client{}
+
+//+kubebuilder:rbac:groups=orderlyape.reviewsignal.com,resources=testruns,verbs=get;list;watch
+//+kubebuilder:rbac:groups=orderlyape.reviewsignal.com,resources=testruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+
+// NewClient returns a client for the TestRun resources in namespace, served as location.
+func NewClient(mgr manager.Manager, location string, namespace string) *Client {
+	return &Client{
+		client:    mgr.GetClient(),
+		reader:    mgr.GetAPIReader(),
+		informers: mgr.GetCache(),
+		location:  location,
+		namespace: namespace,
+	}
+}
+
+func (c *Client) Get(ctx context.Context, id string, obj runtime.Object) error {
+	job, ok := obj.(*api.Job)
+	if !ok {
+		return fmt.Errorf("unsupported type %T", obj)
+	}
+
+	tr := &v1alpha1.TestRun{}
+	if err := c.client.Get(ctx, ctrlclient.ObjectKey{Namespace: c.namespace, Name: id}, tr); err != nil {
+		return statusError(err)
+	}
+
+	// canceling is requested in the spec, but it's a status like any other for the controller
+	if tr.Spec.Cancel && !isFinished(tr.Status.Phase) {
+		tr.Status.Phase = api.STATUS_CANCELED
+		tr.Status.Description = "Test run was canceled"
+		if err := c.client.Status().Update(ctx, tr); err != nil {
+			return statusError(err)
+		}
+	}
+
+	*job = *toJob(tr, c.location)
+
+	if ref := tr.Spec.Output.InfluxDB; ref != nil && ref.TokenSecretRef != nil {
+		token, err := c.secretValue(ctx, ref.TokenSecretRef)
+		if err != nil {
+			return err
+		}
+		job.OutputConfig.InfluxToken = token
+	}
+
+	return nil
+}
+
+func (c *Client) List(ctx context.Context, obj runtime.ObjectList) error {
+	if _, ok := obj.(*api.JobList); !ok {
+		return fmt.Errorf("unsupported type %T", obj)
+	}
+
+	list := &v1alpha1.TestRunList{}
+	if err := c.client.List(ctx, list, ctrlclient.InNamespace(c.namespace)); err != nil {
+		return statusError(err)
+	}
+
+	items := make([]runtime.Object, len(list.Items))
+	for idx := range list.Items {
+		items[idx] = toJob(&list.Items[idx], c.location)
+	}
+	obj.SetItems(items)
+
+	return nil
+}
+
+// Watch sends the jobs of all the TestRun resources, then their changes. Deleted resources are sent too, so their
+// Kubernetes Jobs are cleaned up.
+func (c *Client) Watch(obj runtime.Object) (<-chan runtime.Object, error) {
+	if _, ok := obj.(*api.Job); !ok {
+		return nil, fmt.Errorf("unsupported type %T", obj)
+	}
+
+	informer, err := c.informers.GetInformer(context.Background(), &v1alpha1.TestRun{})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan runtime.Object)
+	send := func(obj interface{}) {
+		if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		tr, ok := obj.(*v1alpha1.TestRun)
+		if !ok || tr.Namespace != c.namespace {
+			return
+		}
+		ch <- toJob(tr, c.location)
+	}
+
+	_, err = informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    send,
+		UpdateFunc: func(_, obj interface{}) { send(obj) },
+		DeleteFunc: send,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("start watching", "namespace", c.namespace)
+	return ch, nil
+}
+
+func (c *Client) Create(_ context.Context, obj runtime.Object) error {
+	if _, ok := obj.(*api.Ping); ok {
+		// there's no webapp to check in with
+		return nil
+	}
+	return errors.New("test runs are created as TestRun resources")
+}
+
+// Update only updates the status, as the spec of a TestRun belongs to its author.
+func (c *Client) Update(ctx context.Context, obj runtime.Object) error {
+	return c.UpdateStatus(ctx, obj)
+}
+
+func (c *Client) UpdateStatus(ctx context.Context, obj runtime.Object) error {
+	job, ok := obj.(*api.Job)
+	if !ok {
+		return fmt.Errorf("unsupported type %T", obj)
+	}
+
+	tr := &v1alpha1.TestRun{}
+	if err := c.client.Get(ctx, ctrlclient.ObjectKey{Namespace: c.namespace, Name: job.Name}, tr); err != nil {
+		return statusError(err)
+	}
+	if job.Version != "" {
+		// the update is rejected if the resource changed since the job was read
+		tr.ResourceVersion = job.Version
+	}
+
+	setStatus(tr, job)
+	if job.Status == api.STATUS_READY && tr.Status.StartTime == nil {
+		startAt := time.Now().Add(StartDelay)
+		if tr.Spec.StartAt != nil {
+			startAt = tr.Spec.StartAt.Time
+		}
+		startTime := metav1.NewTime(startAt.UTC().Truncate(time.Second))
+		tr.Status.StartTime = &startTime
+	}
+
+	if err := c.client.Status().Update(ctx, tr); err != nil {
+		return statusError(err)
+	}
+	job.Version = tr.ResourceVersion
+
+	return nil
+}
+
+func (c *Client) secretValue(ctx context.Context, ref *corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := c.reader.Get(ctx, ctrlclient.ObjectKey{Namespace: c.namespace, Name: ref.Name}, secret); err != nil {
+		return "", statusError(err)
+	}
+
+	value, found := secret.Data[ref.Key]
+	if !found {
+		return "", fmt.Errorf("key %s not found in secret %s", ref.Key, ref.Name)
+	}
+	return string(value), nil
+}
+
+// statusError converts Kubernetes API errors to the errors returned by the webapp API client, so the controller
+// handles them the same way.
+func statusError(err error) error {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		return &client.StatusError{Code: int(status.Status().Code)}
+	}
+	return err
+}
+
+func isFinished(phase string) bool {
+	switch phase {
+	case api.STATUS_CANCELED, api.STATUS_COMPLETED, api.STATUS_FAILED:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package crd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/api/v1alpha1"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+// toJob converts a TestRun resource into the job the webapp would send for it. The InfluxDB token is not set, as
+// it's read from a secret.
+func toJob(tr *v1alpha1.TestRun, location string) *api.Job {
+	workers := tr.Spec.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	job := &api.Job{
+		Name:              tr.Name,
+		Location:          location,
+		Status:            tr.Status.Phase,
+		StatusDescription: tr.Status.Description,
+		Workers:           workers,
+		AssignedSegments:  make([]api.Segment, workers),
+		TestRun: api.TestRun{
+			CreatedAt:       tr.CreationTimestamp.UTC().Format(time.RFC3339),
+			Target:          tr.Spec.Target,
+			EnvVars:         copyMap(tr.Spec.Env),
+			Labels:          copyMap(tr.Spec.Labels),
+			SourceRepo:      tr.Spec.SourceRepo,
+			SourceRef:       tr.Spec.SourceRef,
+			SourceScript:    tr.Spec.SourceScript,
+			Segments:        make([]string, workers+1),
+			ResourceCPU:     tr.Spec.CPU(),
+			ResourceMemory:  tr.Spec.Memory(),
+			NodeSelector:    api.NodeSelector(copyMap(tr.Spec.NodeSelector)),
+			DedicatedNodes:  tr.Spec.DedicatedNodes,
+			LateStartPolicy: tr.Spec.LateStartPolicy,
+		},
+		Conditions:   toConditions(tr.Status.Conditions),
+		Ignition:     toIgnition(tr.Status.Ignition),
+		LocationName: location,
+		Version:      tr.ResourceVersion,
+	}
+	if job.Status == "" {
+		job.Status = api.STATUS_PENDING
+	}
+	if tr.Spec.SourceRef == "" {
+		job.TestRun.SourceRef = "main"
+	}
+
+	// the whole test run is executed in this location
+	for idx := int32(0); idx <= workers; idx++ {
+		job.TestRun.Segments[idx] = segmentPart(idx, workers)
+	}
+	for idx := int32(1); idx <= workers; idx++ {
+		job.AssignedSegments[idx-1] = api.Segment{
+			ID:      strconv.Itoa(int(idx)),
+			Segment: segmentPart(idx-1, workers) + ":" + segmentPart(idx, workers),
+		}
+	}
+
+	if tr.Status.StartTime != nil {
+		startAt := tr.Status.StartTime.Time
+		job.TestRun.StartTestAt = &startAt
+		job.TestRun.Ready = true
+	}
+	if tr.Spec.Deadline != nil {
+		job.TestRun.JobDeadline = &api.Duration{Duration: tr.Spec.Deadline.Duration}
+	}
+	if tr.Spec.LateStartTolerance != nil {
+		job.TestRun.LateStartTolerance = &api.Duration{Duration: tr.Spec.LateStartTolerance.Duration}
+	}
+	if tr.Spec.IgnitionStagger != nil {
+		job.IgnitionStagger = &api.Duration{Duration: tr.Spec.IgnitionStagger.Duration}
+	}
+	if tr.Spec.FailureTolerance != nil {
+		job.TestRun.FailureTolerance = tr.Spec.FailureTolerance.String()
+	}
+	if influxdb := tr.Spec.Output.InfluxDB; influxdb != nil {
+		job.OutputConfig = api.TestOutputConfig{
+			InfluxURL:          influxdb.URL,
+			InfluxOrganization: influxdb.Organization,
+			InfluxBucket:       influxdb.Bucket,
+			TLSSkipVerify:      influxdb.InsecureSkipVerify,
+		}
+	}
+
+	return job
+}
+
+// setStatus copies the status of a job to a TestRun resource.
+func setStatus(tr *v1alpha1.TestRun, job *api.Job) {
+	tr.Status.Phase = job.Status
+	tr.Status.Description = job.StatusDescription
+	tr.Status.Ignition = fromIgnition(job.Ignition)
+	tr.Status.LostSegments = segmentIDs(job.LostSegments)
+	tr.Status.DisruptedSegments = segmentIDs(job.DisruptedSegments)
+	tr.Status.Conditions = fromConditions(job.Conditions, tr.Generation)
+	tr.Status.ObservedGeneration = tr.Generation
+}
+
+// segmentIDs returns the IDs of segments, or nil if there's none.
+func segmentIDs(segments []api.Segment) []string {
+	var ids []string
+	for _, segment := range segments {
+		ids = append(ids, segment.ID)
+	}
+	return ids
+}
+
+// segmentPart returns the index-th of total parts of a test, formatted like the webapp does.
+func segmentPart(index int32, total int32) string {
+	switch index {
+	case 0:
+		return "0"
+	case total:
+		return "1"
+	default:
+		return fmt.Sprintf("%d/%d", index, total)
+	}
+}
+
+func toConditions(conditions []metav1.Condition) []api.Condition {
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	result := make([]api.Condition, len(conditions))
+	for idx, condition := range conditions {
+		result[idx] = api.Condition{
+			Type:               condition.Type,
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.UTC(),
+		}
+	}
+	return result
+}
+
+func fromConditions(conditions []api.Condition, generation int64) []metav1.Condition {
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	result := make([]metav1.Condition, len(conditions))
+	for idx, condition := range conditions {
+		result[idx] = metav1.Condition{
+			Type:               condition.Type,
+			Status:             metav1.ConditionStatus(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: metav1.NewTime(condition.LastTransitionTime),
+			ObservedGeneration: generation,
+		}
+	}
+	return result
+}
+
+func toIgnition(ignition *v1alpha1.Ignition) *api.Ignition {
+	if ignition == nil {
+		return nil
+	}
+
+	result := &api.Ignition{
+		Pods:     make([]api.PodIgnition, len(ignition.Pods)),
+		Spread:   api.Duration{Duration: ignition.Spread.Duration},
+		Lateness: api.Duration{Duration: ignition.Lateness.Duration},
+	}
+	for idx, pod := range ignition.Pods {
+		result.Pods[idx] = api.PodIgnition{Pod: pod.Pod, IgnitedAt: pod.IgnitedAt.Time}
+	}
+	return result
+}
+
+func fromIgnition(ignition *api.Ignition) *v1alpha1.Ignition {
+	if ignition == nil {
+		return nil
+	}
+
+	result := &v1alpha1.Ignition{
+		Pods:     make([]v1alpha1.PodIgnition, len(ignition.Pods)),
+		Spread:   metav1.Duration{Duration: ignition.Spread.Duration},
+		Lateness: metav1.Duration{Duration: ignition.Lateness.Duration},
+	}
+	for idx, pod := range ignition.Pods {
+		result.Pods[idx] = v1alpha1.PodIgnition{Pod: pod.Pod, IgnitedAt: metav1.NewTime(pod.IgnitedAt)}
+	}
+	return result
+}
+
+func copyMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
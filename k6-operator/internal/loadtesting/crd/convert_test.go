@@ -0,0 +1,73 @@
+package crd
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/api/v1alpha1"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+)
+
+var _ = Describe("TestRun conversion", func() {
+	var tr *v1alpha1.TestRun
+
+	BeforeEach(func() {
+		tr = &v1alpha1.TestRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "smoke", Namespace: "loadtesting", Generation: 2},
+			Spec: v1alpha1.TestRunSpec{
+				SourceRepo:   "github.com/example/load-tests",
+				SourceScript: "script.js",
+				Workers:      3,
+			},
+		}
+	})
+
+	It("assigns all the segments to the location, like the webapp", func() {
+		job := toJob(tr, "local")
+
+		Expect(job.Status).To(Equal(api.STATUS_PENDING))
+		Expect(job.TestRun.SourceRef).To(Equal("main"))
+		Expect(job.TestRun.Segments).To(Equal([]string{"0", "1/3", "2/3", "1"}))
+		Expect(job.AssignedSegments).To(Equal([]api.Segment{
+			{ID: "1", Segment: "0:1/3"},
+			{ID: "2", Segment: "1/3:2/3"},
+			{ID: "3", Segment: "2/3:1"},
+		}))
+		Expect(job.TestRun.Labels).NotTo(BeNil())
+	})
+
+	It("is ready to start once a start time is set", func() {
+		Expect(toJob(tr, "local").TestRun.Ready).To(BeFalse())
+
+		startTime := metav1.NewTime(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+		tr.Status.StartTime = &startTime
+		job := toJob(tr, "local")
+
+		Expect(job.TestRun.Ready).To(BeTrue())
+		Expect(*job.TestRun.StartTestAt).To(BeTemporally("==", startTime.Time))
+	})
+
+	It("keeps the status through a round trip", func() {
+		job := toJob(tr, "local")
+		job.Status = api.STATUS_RUNNING
+		job.StatusDescription = "Worker pods are currently running k6 tests"
+		job.SetCondition(api.CONDITION_IGNITED, api.CONDITION_TRUE, "Ignited", "All pods were started within 5ms")
+		job.Ignition = &api.Ignition{
+			Pods:   []api.PodIgnition{{Pod: "smoke-0", IgnitedAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}},
+			Spread: api.Duration{Duration: 5 * time.Millisecond},
+		}
+
+		setStatus(tr, job)
+		Expect(tr.Status.Conditions).To(HaveLen(1))
+		Expect(tr.Status.Conditions[0].ObservedGeneration).To(Equal(int64(2)))
+
+		converted := toJob(tr, "local")
+		Expect(converted.Status).To(Equal(job.Status))
+		Expect(converted.StatusDescription).To(Equal(job.StatusDescription))
+		Expect(converted.Conditions).To(Equal(job.Conditions))
+		Expect(converted.Ignition).To(Equal(job.Ignition))
+	})
+})
@@ -0,0 +1,14 @@
+package crd
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCRD(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "CRD Suite")
+}
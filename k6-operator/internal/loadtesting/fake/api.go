@@ -0,0 +1,419 @@
+// Package fake implements the workers API of the webapp in memory, so the operator can be developed and tested
+// without a running webapp. The same API is available as an HTTP server and as a client.Client.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+)
+
+// transitions are the status changes allowed by the webapp. Jobs can fail or be canceled from any status.
+var transitions = map[string][]string{
+	api.STATUS_PENDING: {api.STATUS_QUEUED},
+	api.STATUS_QUEUED:  {api.STATUS_READY},
+	api.STATUS_READY:   {api.STATUS_RUNNING},
+	api.STATUS_RUNNING: {api.STATUS_COMPLETED},
+	api.STATUS_FAILED:  {api.STATUS_PENDING},
+}
+
+// Location is a location a test run is executed in.
+type Location struct {
+	Name            string        `json:"name"`
+	Workers         int32         `json:"workers"`
+	StartOffset     *api.Duration `json:"start_offset,omitempty"`
+	IgnitionStagger *api.Duration `json:"ignition_stagger,omitempty"`
+}
+
+// Run describes a test run added to the API.
+type Run struct {
+	Name         string               `json:"name"`
+	TestRun      api.TestRun          `json:"test_run"`
+	OutputConfig api.TestOutputConfig `json:"output_config"`
+	Locations    []Location           `json:"locations"`
+}
+
+// Transition is a status change of a job.
+type Transition struct {
+	Location string
+	From     string
+	Job      api.Job
+}
+
+type entry struct {
+	job     api.Job
+	version int64
+}
+
+// API is an in-memory implementation of the workers API. Jobs follow the status transitions of the webapp: the
+// test run gets ready, with a start time, once all of its locations are ready, and is confirmed once all of them
+// reported their ignition. Like the webapp, the worker count of a location follows its sizing when the test run is
+// sized automatically.
+type API struct {
+	// StartDelay is added to the time the last location got ready to get the start time of a test run.
+	StartDelay time.Duration
+
+	mu       sync.Mutex
+	version  int64
+	runs     map[string][]*entry
+	pings    map[string][]api.Ping
+	watchers []*watcher
+	hooks    []func(Transition)
+}
+
+// NewAPI instantiates an empty API.
+func NewAPI() *API {
+	return &API{
+		runs:  map[string][]*entry{},
+		pings: map[string][]api.Ping{},
+	}
+}
+
+// AddRun adds a test run, with a pending job in each of its locations. An existing test run with the same name is
+// replaced.
+func (a *API) AddRun(run Run) error {
+	if run.Name == "" {
+		return fmt.Errorf("a test run name is required")
+	}
+	if len(run.Locations) == 0 {
+		return fmt.Errorf("test run %s has no locations", run.Name)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	testRun := run.TestRun
+	testRun.LastStartOffset = nil
+	for _, location := range run.Locations {
+		job := api.Job{StartOffset: location.StartOffset, IgnitionStagger: location.IgnitionStagger}
+		if offset := job.GetStartOffset() + job.GetIgnitionStagger(); offset > testRun.GetLastStartOffset() {
+			testRun.LastStartOffset = &api.Duration{Duration: offset}
+		}
+	}
+
+	entries := make([]*entry, 0, len(run.Locations))
+	for _, location := range run.Locations {
+		job := api.Job{
+			Name:            run.Name,
+			Location:        location.Name,
+			Status:          api.STATUS_PENDING,
+			Workers:         location.Workers,
+			StartOffset:     location.StartOffset,
+			IgnitionStagger: location.IgnitionStagger,
+			TestRun:         testRun,
+			OutputConfig:    run.OutputConfig,
+		}
+		entries = append(entries, &entry{job: job})
+	}
+
+	a.runs[run.Name] = entries
+	a.updateRun(entries)
+
+	return nil
+}
+
+// DeleteRun removes a test run, as if it was deleted in the webapp.
+func (a *API) DeleteRun(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.runs, name)
+}
+
+// Job returns the job of a test run in a location.
+func (a *API) Job(location string, name string) (api.Job, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	e := a.find(location, name)
+	if e == nil {
+		return api.Job{}, false
+	}
+	return a.snapshot(e), true
+}
+
+// Jobs returns the jobs of a location, sorted by name.
+func (a *API) Jobs(location string) []api.Job {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	jobs := []api.Job{}
+	for _, entries := range a.runs {
+		for _, e := range entries {
+			if e.job.Location == location {
+				jobs = append(jobs, a.snapshot(e))
+			}
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Name < jobs[j].Name
+	})
+
+	return jobs
+}
+
+// SetStatus changes the status of a job, following the same rules as the webapp. The description is kept if
+// empty.
+func (a *API) SetStatus(location string, name string, status string, description string) error {
+	return a.update(location, name, "", func(job *api.Job) error {
+		if description != "" {
+			job.StatusDescription = description
+		}
+		return transition(job, status)
+	})
+}
+
+// Fail fails the job of a test run in a location.
+func (a *API) Fail(location string, name string, message string) error {
+	return a.SetStatus(location, name, api.STATUS_FAILED, message)
+}
+
+// Cancel cancels a test run in all of its locations, like users do from the webapp.
+func (a *API) Cancel(name string, message string) error {
+	a.mu.Lock()
+	entries, found := a.runs[name]
+	a.mu.Unlock()
+	if !found {
+		return &client.StatusError{Code: 404}
+	}
+
+	for _, e := range entries {
+		if err := a.SetStatus(e.job.Location, name, api.STATUS_CANCELED, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pings returns the pings received from a location.
+func (a *API) Pings(location string) []api.Ping {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]api.Ping{}, a.pings[location]...)
+}
+
+// OnTransition registers a function called after every status change. It's used to script the lifecycle of jobs,
+// for example failing a job as soon as it's queued. The function must not block.
+func (a *API) OnTransition(fn func(Transition)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.hooks = append(a.hooks, fn)
+}
+
+// WaitForStatus waits until the job of a test run in a location reaches status, or the context is done.
+func (a *API) WaitForStatus(ctx context.Context, location string, name string, status string) (api.Job, error) {
+	t := time.NewTicker(50 * time.Millisecond)
+	defer t.Stop()
+
+	for {
+		if job, found := a.Job(location, name); found && job.Status == status {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			job, _ := a.Job(location, name)
+			return job, fmt.Errorf("job %s in %s is %s, not %s: %w", name, location, job.Status, status, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// ping records a ping from a location.
+func (a *API) ping(location string, ping api.Ping) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pings[location] = append(a.pings[location], ping)
+}
+
+// update applies fn to a job and records the change. If version is set, the update is rejected when the job
+// changed since.
+func (a *API) update(location string, name string, version string, fn func(job *api.Job) error) error {
+	a.mu.Lock()
+
+	e := a.find(location, name)
+	if e == nil {
+		a.mu.Unlock()
+		return &client.StatusError{Code: 404}
+	}
+	if version != "" && version != etag(e.version) {
+		a.mu.Unlock()
+		return &client.StatusError{Code: 412}
+	}
+
+	job := e.job
+	if err := fn(&job); err != nil {
+		a.mu.Unlock()
+		return err
+	}
+
+	from := e.job.Status
+	e.job = job
+	a.updateRun(a.runs[name])
+	a.changed(e)
+
+	var hooks []func(Transition)
+	if from != job.Status {
+		hooks = a.hooks
+	}
+	snapshot := a.snapshot(e)
+	a.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(Transition{Location: location, From: from, Job: snapshot})
+	}
+
+	return nil
+}
+
+// updateRun updates the test run fields which depend on all the locations: the status, and the segments which
+// follow the worker count of every location.
+func (a *API) updateRun(entries []*entry) {
+	ready, completed, confirmed, sized := true, true, true, true
+	total := int32(0)
+	for _, e := range entries {
+		ready = ready && e.job.Status == api.STATUS_READY
+		completed = completed && e.job.Status == api.STATUS_COMPLETED
+		confirmed = confirmed && e.job.Ignition != nil
+		sized = sized && e.job.Sizing != nil
+		total += e.job.Workers
+	}
+
+	start := int32(0)
+	for _, e := range entries {
+		changed := false
+		testRun := &e.job.TestRun
+
+		sequence, assigned := segments(start, e.job.Workers, total)
+		start += e.job.Workers
+		if !slices.Equal(testRun.Segments, sequence) || !slices.Equal(e.job.AssignedSegments, assigned) {
+			testRun.Segments = sequence
+			e.job.AssignedSegments = assigned
+			changed = true
+		}
+
+		if ready && testRun.StartTestAt == nil {
+			startAt := time.Now().Add(a.StartDelay).UTC()
+			testRun.StartTestAt = &startAt
+		}
+		if testRun.Ready != ready || testRun.Completed != completed || testRun.Confirmed != confirmed || testRun.Sized != sized {
+			testRun.Ready = ready
+			testRun.Completed = completed
+			testRun.Confirmed = confirmed
+			testRun.Sized = sized
+			changed = true
+		}
+
+		if changed {
+			a.changed(e)
+		}
+	}
+}
+
+// changed bumps the version of a job and notifies the watchers.
+func (a *API) changed(e *entry) {
+	a.version++
+	e.version = a.version
+	e.job.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+
+	for _, w := range a.watchers {
+		if w.location == e.job.Location {
+			w.push(a.snapshot(e))
+		}
+	}
+}
+
+func (a *API) find(location string, name string) *entry {
+	for _, e := range a.runs[name] {
+		if e.job.Location == location {
+			return e
+		}
+	}
+	return nil
+}
+
+// snapshot returns a copy of a job, safe to be used without holding the lock.
+func (a *API) snapshot(e *entry) api.Job {
+	job := e.job
+	job.LocationName = job.Location
+	job.Version = etag(e.version)
+	job.AssignedSegments = append([]api.Segment{}, job.AssignedSegments...)
+	job.Conditions = append([]api.Condition{}, job.Conditions...)
+	job.TestRun.EnvVars = copyMap(job.TestRun.EnvVars)
+	job.TestRun.Labels = copyMap(job.TestRun.Labels)
+	return job
+}
+
+// transition changes the status of a job, if the webapp allows it.
+func transition(job *api.Job, status string) error {
+	if status == "" || status == job.Status {
+		return nil
+	}
+
+	allowed := status == api.STATUS_FAILED || status == api.STATUS_CANCELED
+	for _, target := range transitions[job.Status] {
+		allowed = allowed || target == status
+	}
+	if !allowed {
+		return &client.StatusError{Code: 400}
+	}
+
+	job.Status = status
+	return nil
+}
+
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// segments returns the segment sequence of a test run with total workers, and the segments assigned to the
+// workers of a location, starting at index start.
+func segments(start int32, workers int32, total int32) ([]string, []api.Segment) {
+	sequence := []string{"0"}
+	for idx := int32(1); idx < total; idx++ {
+		sequence = append(sequence, segmentPart(idx, total))
+	}
+	sequence = append(sequence, "1")
+
+	var assigned []api.Segment
+	for idx := start + 1; idx <= start+workers; idx++ {
+		assigned = append(assigned, api.Segment{
+			ID:      strconv.Itoa(int(idx)),
+			Segment: segmentPart(idx-1, total) + ":" + segmentPart(idx, total),
+		})
+	}
+
+	return sequence, assigned
+}
+
+func segmentPart(index int32, total int32) string {
+	switch index {
+	case 0:
+		return "0"
+	case total:
+		return "1"
+	default:
+		return fmt.Sprintf("%d/%d", index, total)
+	}
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
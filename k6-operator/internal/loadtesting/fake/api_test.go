@@ -0,0 +1,204 @@
+package fake
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+)
+
+var _ = Describe("API", func() {
+	var a *API
+
+	BeforeEach(func() {
+		a = NewAPI()
+		Expect(a.AddRun(Run{
+			Name:      "run-1",
+			TestRun:   api.TestRun{Target: "https://example.com", NodeSelector: api.NodeSelector{"pool": "k6"}},
+			Locations: []Location{{Name: "eu", Workers: 1}, {Name: "us", Workers: 2}},
+		})).To(Succeed())
+	})
+
+	It("splits the test run in segments across locations", func() {
+		eu, found := a.Job("eu", "run-1")
+		Expect(found).To(BeTrue())
+		Expect(eu.Status).To(Equal(api.STATUS_PENDING))
+		Expect(eu.TestRun.Segments).To(Equal([]string{"0", "1/3", "2/3", "1"}))
+		Expect(eu.AssignedSegments).To(Equal([]api.Segment{{ID: "1", Segment: "0:1/3"}}))
+
+		us, _ := a.Job("us", "run-1")
+		Expect(us.AssignedSegments).To(Equal([]api.Segment{{ID: "2", Segment: "1/3:2/3"}, {ID: "3", Segment: "2/3:1"}}))
+	})
+
+	It("reports when the last location is done starting", func() {
+		Expect(a.AddRun(Run{
+			Name: "run-2",
+			Locations: []Location{
+				{Name: "eu", Workers: 1, StartOffset: &api.Duration{Duration: time.Minute}},
+				{Name: "us", Workers: 2, StartOffset: &api.Duration{Duration: 30 * time.Second}, IgnitionStagger: &api.Duration{Duration: time.Minute}},
+			},
+		})).To(Succeed())
+
+		eu, _ := a.Job("eu", "run-2")
+		Expect(eu.GetStartOffset()).To(Equal(time.Minute))
+		Expect(eu.GetIgnitionStagger()).To(BeZero())
+		Expect(eu.TestRun.GetLastStartOffset()).To(Equal(90 * time.Second))
+	})
+
+	It("recomputes the segments once the locations are sized automatically", func() {
+		Expect(a.AddRun(Run{
+			Name:      "run-2",
+			TestRun:   api.TestRun{Sizing: api.SIZING_AUTO},
+			Locations: []Location{{Name: "eu", Workers: 1}, {Name: "us", Workers: 1}},
+		})).To(Succeed())
+
+		c := a.Client("eu")
+		job := &api.Job{}
+		Expect(c.Get(context.Background(), "run-2", job)).To(Succeed())
+		job.Sizing = &api.Sizing{MaxVUs: 300, Workers: 2}
+		Expect(c.UpdateStatus(context.Background(), job)).To(Succeed())
+		Expect(job.TestRun.Sized).To(BeFalse())
+		Expect(job.AssignedSegments).To(Equal([]api.Segment{{ID: "1", Segment: "0:1/3"}, {ID: "2", Segment: "1/3:2/3"}}))
+
+		us, _ := a.Job("us", "run-2")
+		us.Sizing = &api.Sizing{MaxVUs: 300, Workers: 1}
+		Expect(a.Client("us").UpdateStatus(context.Background(), &us)).To(Succeed())
+		Expect(us.TestRun.Sized).To(BeTrue())
+		Expect(us.TestRun.Segments).To(Equal([]string{"0", "1/3", "2/3", "1"}))
+		Expect(us.AssignedSegments).To(Equal([]api.Segment{{ID: "3", Segment: "2/3:1"}}))
+	})
+
+	It("follows the status transitions of the webapp", func() {
+		Expect(a.SetStatus("eu", "run-1", api.STATUS_RUNNING, "")).To(MatchError(&client.StatusError{Code: 400}))
+		Expect(a.SetStatus("eu", "run-1", api.STATUS_QUEUED, "")).To(Succeed())
+		Expect(a.SetStatus("eu", "run-1", api.STATUS_READY, "")).To(Succeed())
+		Expect(a.Fail("eu", "run-1", "no nodes")).To(Succeed())
+
+		job, _ := a.Job("eu", "run-1")
+		Expect(job.Status).To(Equal(api.STATUS_FAILED))
+		Expect(job.StatusDescription).To(Equal("no nodes"))
+		Expect(a.SetStatus("eu", "run-1", api.STATUS_PENDING, "")).To(Succeed())
+	})
+
+	It("starts the test run once all the locations are ready", func() {
+		for _, location := range []string{"eu", "us"} {
+			Expect(a.SetStatus(location, "run-1", api.STATUS_QUEUED, "")).To(Succeed())
+			Expect(a.SetStatus(location, "run-1", api.STATUS_READY, "")).To(Succeed())
+		}
+
+		for _, location := range []string{"eu", "us"} {
+			job, _ := a.Job(location, "run-1")
+			Expect(job.TestRun.Ready).To(BeTrue())
+			Expect(job.TestRun.StartTestAt).NotTo(BeNil())
+		}
+	})
+
+	It("confirms the start once all the locations reported their ignition", func() {
+		ignition := &api.Ignition{Pods: []api.PodIgnition{{Pod: "pod-0", IgnitedAt: time.Now()}}}
+		for _, location := range []string{"eu", "us"} {
+			Expect(a.SetStatus(location, "run-1", api.STATUS_QUEUED, "")).To(Succeed())
+			Expect(a.SetStatus(location, "run-1", api.STATUS_READY, "")).To(Succeed())
+		}
+
+		c := a.Client("eu")
+		job := &api.Job{}
+		Expect(c.Get(context.Background(), "run-1", job)).To(Succeed())
+		job.Status = api.STATUS_RUNNING
+		job.Ignition = ignition
+		Expect(c.UpdateStatus(context.Background(), job)).To(Succeed())
+		Expect(job.TestRun.Confirmed).To(BeFalse())
+
+		job = &api.Job{}
+		Expect(a.Client("us").Get(context.Background(), "run-1", job)).To(Succeed())
+		job.Status = api.STATUS_RUNNING
+		job.Ignition = ignition
+		Expect(a.Client("us").UpdateStatus(context.Background(), job)).To(Succeed())
+		Expect(job.TestRun.Confirmed).To(BeTrue())
+	})
+
+	It("calls the hooks on transitions", func() {
+		a.OnTransition(func(t Transition) {
+			if t.Job.Status == api.STATUS_QUEUED {
+				go func() { _ = a.Fail(t.Location, t.Job.Name, "scripted") }()
+			}
+		})
+		Expect(a.SetStatus("eu", "run-1", api.STATUS_QUEUED, "")).To(Succeed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		job, err := a.WaitForStatus(ctx, "eu", "run-1", api.STATUS_FAILED)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.StatusDescription).To(Equal("scripted"))
+	})
+
+	Describe("Client", func() {
+		It("rejects stale updates", func() {
+			c := a.Client("eu")
+			job := &api.Job{}
+			Expect(c.Get(context.Background(), "run-1", job)).To(Succeed())
+			Expect(a.SetStatus("eu", "run-1", api.STATUS_QUEUED, "")).To(Succeed())
+
+			job.Status = api.STATUS_CANCELED
+			Expect(client.IsConflict(c.UpdateStatus(context.Background(), job))).To(BeTrue())
+		})
+
+		It("watches the jobs of its location", func() {
+			ch, err := a.Client("us").Watch(&api.Job{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(ch).Should(Receive(HaveField("Status", api.STATUS_PENDING)))
+
+			Expect(a.Cancel("run-1", "stop")).To(Succeed())
+			Eventually(ch).Should(Receive(HaveField("Status", api.STATUS_CANCELED)))
+		})
+	})
+
+	Describe("Server", func() {
+		var (
+			server *httptest.Server
+			c      *client.UncachedClient
+		)
+
+		BeforeEach(func() {
+			server = NewServer(a)
+
+			var err error
+			c, err = client.NewUncachedClient(client.WithBaseURL(server.URL+"/api/"), client.WithRegion("eu"), client.WithRetries(0, 0))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("serves the workers API", func() {
+			Expect(c.Create(context.Background(), &api.Ping{})).To(Succeed())
+			Expect(a.Pings("eu")).To(HaveLen(1))
+
+			jobs := &api.JobList{}
+			Expect(c.List(context.Background(), jobs)).To(Succeed())
+			Expect(jobs.Items).To(HaveLen(1))
+			Expect(jobs.Items[0].TestRun.NodeSelector).To(Equal(api.NodeSelector{"pool": "k6"}))
+
+			job := &jobs.Items[0]
+			Expect(c.Get(context.Background(), "run-1", job)).To(Succeed())
+			job.Status = api.STATUS_QUEUED
+			job.OnlineWorkers = 1
+			Expect(c.UpdateStatus(context.Background(), job)).To(Succeed())
+			Expect(job.Status).To(Equal(api.STATUS_QUEUED))
+
+			job.Status = api.STATUS_COMPLETED
+			err := c.UpdateStatus(context.Background(), job)
+			Expect(err).To(BeAssignableToTypeOf(&client.StatusError{}))
+			Expect(err.(*client.StatusError).Code).To(Equal(400))
+		})
+
+		It("reports missing jobs", func() {
+			Expect(client.IsNotFound(c.Get(context.Background(), "missing", &api.Job{}))).To(BeTrue())
+		})
+	})
+})
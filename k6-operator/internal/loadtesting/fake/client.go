@@ -0,0 +1,173 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/runtime"
+)
+
+// Client is a client.Client for the jobs of a location, talking directly to an in-memory API.
+type Client struct {
+	api      *API
+	location string
+}
+
+var _ client.Client = &Client{}
+
+// Client returns a client for the jobs of a location.
+func (a *API) Client(location string) *Client {
+	return &Client{api: a, location: location}
+}
+
+func (c *Client) Get(_ context.Context, id string, obj runtime.Object) error {
+	job, ok := obj.(*api.Job)
+	if !ok {
+		return fmt.Errorf("unsupported type %T", obj)
+	}
+
+	found, exists := c.api.Job(c.location, id)
+	if !exists {
+		return &client.StatusError{Code: 404}
+	}
+	*job = found
+	return nil
+}
+
+func (c *Client) List(_ context.Context, obj runtime.ObjectList) error {
+	list, ok := obj.(*api.JobList)
+	if !ok {
+		return fmt.Errorf("unsupported type %T", obj)
+	}
+
+	list.Items = c.api.Jobs(c.location)
+	return nil
+}
+
+// Watch sends all the jobs of the location, then every change. Changes are never dropped, so slow readers see all
+// the intermediate states.
+func (c *Client) Watch(obj runtime.Object) (<-chan runtime.Object, error) {
+	if _, ok := obj.(*api.Job); !ok {
+		return nil, fmt.Errorf("unsupported type %T", obj)
+	}
+
+	w := newWatcher(c.location)
+	c.api.mu.Lock()
+	for _, entries := range c.api.runs {
+		for _, e := range entries {
+			if e.job.Location == c.location {
+				w.push(c.api.snapshot(e))
+			}
+		}
+	}
+	c.api.watchers = append(c.api.watchers, w)
+	c.api.mu.Unlock()
+
+	return w.ch, nil
+}
+
+func (c *Client) Create(_ context.Context, obj runtime.Object) error {
+	ping, ok := obj.(*api.Ping)
+	if !ok {
+		return fmt.Errorf("unsupported type %T", obj)
+	}
+
+	c.api.ping(c.location, *ping)
+	return nil
+}
+
+// Update updates the status of a job, as the rest of it is read only for the workers.
+func (c *Client) Update(ctx context.Context, obj runtime.Object) error {
+	return c.update(obj, false)
+}
+
+// UpdateStatus updates the status of a job. Like the status endpoint of the webapp, it's rejected if the job
+// changed since the version the status was decided on.
+func (c *Client) UpdateStatus(ctx context.Context, obj runtime.Object) error {
+	return c.update(obj, true)
+}
+
+func (c *Client) update(obj runtime.Object, checkUpdatedAt bool) error {
+	job, ok := obj.(*api.Job)
+	if !ok {
+		return fmt.Errorf("unsupported type %T", obj)
+	}
+
+	err := c.api.update(c.location, job.Name, job.Version, func(current *api.Job) error {
+		if checkUpdatedAt && job.UpdatedAt != "" && job.UpdatedAt != current.UpdatedAt {
+			return &client.StatusError{Code: 409}
+		}
+		if err := transition(current, job.Status); err != nil {
+			return err
+		}
+		current.StatusDescription = job.StatusDescription
+		current.OnlineWorkers = job.OnlineWorkers
+		current.Ignition = job.Ignition
+		current.Sizing = job.Sizing
+		current.LostSegments = job.LostSegments
+		current.DisruptedSegments = job.DisruptedSegments
+		if current.TestRun.GetSizing() == api.SIZING_AUTO && job.Sizing != nil && job.Sizing.Workers > 0 {
+			current.Workers = job.Sizing.Workers
+		}
+		current.Conditions = append([]api.Condition{}, job.Conditions...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	updated, _ := c.api.Job(c.location, job.Name)
+	*job = updated
+	return nil
+}
+
+// watcher queues the changes of the jobs of a location, so the API never blocks on a slow reader.
+type watcher struct {
+	location string
+	ch       chan runtime.Object
+
+	mu      sync.Mutex
+	queue   []api.Job
+	pending chan struct{}
+}
+
+func newWatcher(location string) *watcher {
+	w := &watcher{
+		location: location,
+		ch:       make(chan runtime.Object),
+		pending:  make(chan struct{}, 1),
+	}
+	go w.run()
+	return w
+}
+
+func (w *watcher) push(job api.Job) {
+	w.mu.Lock()
+	w.queue = append(w.queue, job)
+	w.mu.Unlock()
+
+	select {
+	case w.pending <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) run() {
+	for range w.pending {
+		for {
+			w.mu.Lock()
+			if len(w.queue) == 0 {
+				w.mu.Unlock()
+				break
+			}
+			job := w.queue[0]
+			w.queue = w.queue[1:]
+			w.mu.Unlock()
+
+			w.ch <- &job
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package fake
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+)
+
+// NewServer starts an HTTP server serving the API. It must be closed when it's no longer needed.
+func NewServer(a *API) *httptest.Server {
+	return httptest.NewServer(a.Handler())
+}
+
+// Handler returns an HTTP handler serving the workers API, under any path prefix, like `/api/workers/...`. Test
+// runs are managed through `/runs`:
+//
+//	POST   /runs               adds the Run in the body
+//	DELETE /runs/<name>        removes a test run
+//	POST   /runs/<name>/cancel cancels a test run in all of its locations
+func (a *API) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if idx := strings.Index(r.URL.Path, "/workers/"); idx >= 0 {
+			a.serveWorkers(w, r, splitPath(r.URL.Path[idx+len("/workers/"):]))
+			return
+		}
+		if idx := strings.Index(r.URL.Path, "/runs"); idx >= 0 {
+			a.serveRuns(w, r, splitPath(r.URL.Path[idx+len("/runs"):]))
+			return
+		}
+		writeError(w, http.StatusNotFound)
+	})
+}
+
+// serveWorkers serves `workers/<location>/ping` and `workers/<location>/jobs[/<name>[/status]]`.
+func (a *API) serveWorkers(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) < 2 {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+	c := a.Client(parts[0])
+
+	switch {
+	case len(parts) == 2 && parts[1] == "ping":
+		if !allowMethods(w, r, http.MethodPost) {
+			return
+		}
+		ping := &api.Ping{}
+		if !readJSON(w, r, ping) {
+			return
+		}
+		_ = c.Create(r.Context(), ping)
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 2 && parts[1] == "jobs":
+		if !allowMethods(w, r, http.MethodGet) {
+			return
+		}
+		writeJSON(w, http.StatusOK, a.Jobs(c.location))
+
+	case len(parts) == 3 && parts[1] == "jobs":
+		if !allowMethods(w, r, http.MethodGet, http.MethodPut, http.MethodPatch) {
+			return
+		}
+		job := &api.Job{}
+		if r.Method != http.MethodGet {
+			if !readJSON(w, r, job) {
+				return
+			}
+			job.Name = parts[2]
+			job.Version = r.Header.Get("If-Match")
+			if err := c.Update(r.Context(), job); err != nil {
+				writeError(w, errorCode(err))
+				return
+			}
+		} else if err := c.Get(r.Context(), parts[2], job); err != nil {
+			writeError(w, errorCode(err))
+			return
+		}
+		w.Header().Set("ETag", job.Version)
+		writeJSON(w, http.StatusOK, job)
+
+	case len(parts) == 4 && parts[1] == "jobs" && parts[3] == "status":
+		if !allowMethods(w, r, http.MethodPatch) {
+			return
+		}
+		status := &api.JobStatus{}
+		if !readJSON(w, r, status) {
+			return
+		}
+		job := &api.Job{
+			Name:              parts[2],
+			Status:            status.Status,
+			StatusDescription: status.StatusDescription,
+			Ignition:          status.Ignition,
+			Sizing:            status.Sizing,
+			LostSegments:      status.LostSegments,
+			DisruptedSegments: status.DisruptedSegments,
+			Conditions:        status.Conditions,
+			UpdatedAt:         status.UpdatedAt,
+			Version:           r.Header.Get("If-Match"),
+		}
+		// the online workers aren't part of the status payload
+		if current, found := a.Job(c.location, job.Name); found {
+			job.OnlineWorkers = current.OnlineWorkers
+		}
+		if err := c.UpdateStatus(r.Context(), job); err != nil {
+			writeError(w, errorCode(err))
+			return
+		}
+		w.Header().Set("ETag", job.Version)
+		writeJSON(w, http.StatusOK, job)
+
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+// serveRuns serves `runs[/<name>[/cancel]]`.
+func (a *API) serveRuns(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 0:
+		if !allowMethods(w, r, http.MethodPost) {
+			return
+		}
+		run := Run{}
+		if !readJSON(w, r, &run) {
+			return
+		}
+		if err := a.AddRun(run); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	case len(parts) == 1:
+		if !allowMethods(w, r, http.MethodDelete) {
+			return
+		}
+		a.DeleteRun(parts[0])
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 2 && parts[1] == "cancel":
+		if !allowMethods(w, r, http.MethodPost) {
+			return
+		}
+		body := struct {
+			Message string `json:"message"`
+		}{}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		if err := a.Cancel(parts[0], body.Message); err != nil {
+			writeError(w, errorCode(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+func splitPath(path string) []string {
+	parts := []string{}
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed)
+	return false
+}
+
+// readJSON decodes the request body into v. An empty body is accepted.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int) {
+	writeJSON(w, code, map[string]string{"detail": http.StatusText(code)})
+}
+
+func errorCode(err error) int {
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,14 @@
+package loadtesting
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLoadtesting(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Loadtesting Suite")
+}
@@ -0,0 +1,32 @@
+package loadtesting
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	credentialsReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "orderly_ape_credentials_reloads_total",
+			Help: "Number of API credentials reloads, by result.",
+		},
+		[]string{"result"},
+	)
+	credentialsLastReload = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "orderly_ape_credentials_last_reload_timestamp_seconds",
+			Help: "Timestamp of the last successful API credentials reload.",
+		},
+	)
+	pendingStatusUpdates = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "orderly_ape_pending_status_updates",
+			Help: "Number of status updates waiting to be sent to the API.",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(credentialsReloads, credentialsLastReload, pendingStatusUpdates)
+}
@@ -0,0 +1,138 @@
+package loadtesting
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader holds the hex encoded HMAC-SHA256 of "<timestamp>.<body>", prefixed by "sha256=".
+	SignatureHeader = "X-Orderly-Ape-Signature"
+	// TimestampHeader holds the unix time the notification was sent at.
+	TimestampHeader = "X-Orderly-Ape-Timestamp"
+
+	maxNotificationAge  = 5 * time.Minute
+	maxNotificationSize = 64 * 1024
+)
+
+// Notifier reconciles a job right away.
+type Notifier interface {
+	Notify(ctx context.Context, name string) error
+}
+
+// Notification is sent by the webapp when a job changes.
+type Notification struct {
+	// Location is the name of the location the job belongs to. It can be omitted if the operator serves a single
+	// location.
+	Location string `json:"location,omitempty"`
+	Job      string `json:"job"`
+}
+
+// NotificationHandler receives the job change notifications sent by the webapp, and passes them to the notifier
+// of their location. Notifications are authenticated with a shared secret.
+type NotificationHandler struct {
+	secret    []byte
+	notifiers map[string]Notifier
+}
+
+// NewNotificationHandler instantiate a notification handler, with a notifier for every location.
+func NewNotificationHandler(secret []byte, notifiers map[string]Notifier) (*NotificationHandler, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("notifications secret is empty")
+	}
+
+	h := &NotificationHandler{
+		secret:    secret,
+		notifiers: notifiers,
+	}
+
+	return h, nil
+}
+
+// Sign returns the signature of a notification, sent at timestamp.
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxNotificationSize))
+	if err != nil {
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verify(r.Header, body); err != nil {
+		log.V(1).Info("rejected notification", "error", err.Error(), "remote", r.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	notification := Notification{}
+	if err := json.Unmarshal(body, &notification); err != nil || notification.Job == "" {
+		http.Error(w, "invalid notification", http.StatusBadRequest)
+		return
+	}
+
+	notifier, found := h.notifiers[notification.Location]
+	if !found && notification.Location == "" && len(h.notifiers) == 1 {
+		for _, only := range h.notifiers {
+			notifier, found = only, true
+		}
+	}
+	if !found {
+		http.Error(w, "unknown location", http.StatusNotFound)
+		return
+	}
+
+	log.V(1).Info("received notification", "location", notification.Location, "job", notification.Job)
+	if err := notifier.Notify(r.Context(), notification.Job); err != nil {
+		if IsNotFound(err) {
+			http.Error(w, "unknown job", http.StatusNotFound)
+			return
+		}
+		log.Error(err, "handling notification", "location", notification.Location, "job", notification.Job)
+		http.Error(w, "can't handle notification", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verify checks the signature and the age of a notification.
+func (h *NotificationHandler) verify(header http.Header, body []byte) error {
+	timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return errors.New("missing timestamp")
+	}
+
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > maxNotificationAge || age < -maxNotificationAge {
+		return errors.New("notification is too old")
+	}
+
+	signature := strings.TrimSpace(header.Get(SignatureHeader))
+	if !hmac.Equal([]byte(signature), []byte(Sign(h.secret, timestamp, body))) {
+		return errors.New("signature mismatch")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package loadtesting
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type recordingNotifier struct {
+	names []string
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, name string) error {
+	n.names = append(n.names, name)
+	return nil
+}
+
+var _ = Describe("NotificationHandler", func() {
+	secret := []byte("s3cr3t")
+
+	var (
+		notifier *recordingNotifier
+		handler  *NotificationHandler
+	)
+
+	BeforeEach(func() {
+		notifier = &recordingNotifier{}
+		var err error
+		handler, err = NewNotificationHandler(secret, map[string]Notifier{"us-east-1": notifier})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	post := func(body string, sentAt time.Time, key []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/notify", bytes.NewBufferString(body))
+		req.Header.Set(TimestampHeader, strconv.FormatInt(sentAt.Unix(), 10))
+		req.Header.Set(SignatureHeader, Sign(key, sentAt.Unix(), []byte(body)))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	It("notifies the location of the job", func() {
+		Expect(post(`{"location": "us-east-1", "job": "job-1"}`, time.Now(), secret)).To(Equal(http.StatusAccepted))
+		Expect(notifier.names).To(Equal([]string{"job-1"}))
+	})
+
+	It("defaults to the only location", func() {
+		Expect(post(`{"job": "job-1"}`, time.Now(), secret)).To(Equal(http.StatusAccepted))
+		Expect(notifier.names).To(Equal([]string{"job-1"}))
+	})
+
+	It("rejects unknown locations", func() {
+		Expect(post(`{"location": "eu-west-1", "job": "job-1"}`, time.Now(), secret)).To(Equal(http.StatusNotFound))
+	})
+
+	It("rejects invalid signatures", func() {
+		Expect(post(`{"job": "job-1"}`, time.Now(), []byte("wrong"))).To(Equal(http.StatusUnauthorized))
+		Expect(notifier.names).To(BeEmpty())
+	})
+
+	It("rejects replayed notifications", func() {
+		Expect(post(`{"job": "job-1"}`, time.Now().Add(-time.Hour), secret)).To(Equal(http.StatusUnauthorized))
+		Expect(notifier.names).To(BeEmpty())
+	})
+})
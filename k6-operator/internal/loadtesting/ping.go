@@ -39,7 +39,15 @@ func (p *Pinger) Start(ctx context.Context) error {
 }
 
 func (p *Pinger) Ping(ctx context.Context) {
-	err := p.client.Create(ctx, &api.Ping{})
+	ping := &api.Ping{}
+	if clock, ok := p.client.(client.ClockSource); ok {
+		if offset, known := clock.ClockOffset(); known {
+			ping.ClockSkew = &api.Duration{Duration: offset}
+			log.V(1).Info("measured clock skew", "skew", offset)
+		}
+	}
+
+	err := p.client.Create(ctx, ping)
 	if err != nil {
 		log.Error(err, "ping home")
 	}
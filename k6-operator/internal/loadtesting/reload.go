@@ -0,0 +1,85 @@
+package loadtesting
+
+import (
+	"context"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Reloader watches a set of directories and calls a reload function when files in them change.
+// Kubernetes updates mounted secrets by atomically swapping a symlink, so watching the directory is required to
+// be notified.
+type Reloader struct {
+	dirs     []string
+	reload   func() error
+	debounce time.Duration
+}
+
+// NewReloader instantiate a reloader.
+func NewReloader(reload func() error, dirs ...string) (*Reloader, error) {
+	r := &Reloader{
+		dirs:     dirs,
+		reload:   reload,
+		debounce: 2 * time.Second,
+	}
+
+	return r, nil
+}
+
+// NeedLeaderElection returns false, as all the replicas need up to date credentials.
+func (r *Reloader) NeedLeaderElection() bool {
+	return false
+}
+
+// Start watches for changes until the context is done. It's designed to be run by a manager.
+func (r *Reloader) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, dir := range r.dirs {
+		if err := watcher.Add(dir); err != nil {
+			log.Error(err, "can't watch for credential changes", "dir", dir)
+		}
+	}
+
+	// changes come in bursts, so we wait for them to settle before reloading
+	timer := time.NewTimer(r.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			log.V(1).Info("credentials changed", "file", event.Name, "op", event.Op.String())
+			timer.Reset(r.debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error(err, "watching for credential changes")
+		case <-timer.C:
+			r.Reload()
+		}
+	}
+}
+
+// Reload calls the reload function and records the result.
+func (r *Reloader) Reload() {
+	if err := r.reload(); err != nil {
+		log.Error(err, "reloading credentials")
+		credentialsReloads.WithLabelValues("error").Inc()
+		return
+	}
+
+	log.Info("reloaded credentials")
+	credentialsReloads.WithLabelValues("success").Inc()
+	credentialsLastReload.SetToCurrentTime()
+}
@@ -13,3 +13,22 @@ type ObjectList interface {
 	GetItem() Object
 	SetItems([]Object)
 }
+
+// Located is implemented by objects which belong to a location. The client sets the location the object was
+// retrieved for.
+type Located interface {
+	SetLocationName(name string)
+}
+
+// Statused is implemented by objects whose status can be updated on its own, through the status endpoint.
+type Statused interface {
+	// StatusPayload returns the body sent to the status endpoint.
+	StatusPayload() interface{}
+}
+
+// Versioned is implemented by objects supporting optimistic concurrency. The client records the version (ETag)
+// of the object and sends it back with updates, so they are rejected if the object changed in the meantime.
+type Versioned interface {
+	GetVersion() string
+	SetVersion(version string)
+}
@@ -84,6 +84,25 @@ func (s *Scheme) NewObj(kind string) (Object, error) {
 	return obj, nil
 }
 
+// NewList is a helper method that dynamically creates an empty list for a registered type or type list.
+func (s *Scheme) NewList(kind string) (ObjectList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.objToRecord[kind]
+	if !exists {
+		return nil, fmt.Errorf(missingObjTypeFmt, kind)
+	}
+	reflectType := record.ListType
+
+	list, ok := (reflect.New(reflectType).Interface()).(ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("%s doesn't implement interface ObjectList", reflectType)
+	}
+
+	return list, nil
+}
+
 // GetEndpointForObj returns the registered endpoint for a single object.
 func (s *Scheme) GetEndpointForObj(obj interface{}) (string, error) {
 	typeName := RealTypeOf(obj).String()
@@ -0,0 +1,151 @@
+package loadtesting
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/runtime"
+)
+
+// StatusQueue sends status updates to the API. Updates failing with a transient error are queued and replayed
+// until they succeed, so a webapp outage doesn't leave the test runs out of sync.
+type StatusQueue struct {
+	client   client.Client
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]runtime.Object
+}
+
+// NewStatusQueue instantiate a status queue.
+func NewStatusQueue(client client.Client) (*StatusQueue, error) {
+	q := &StatusQueue{
+		client:   client,
+		interval: 10 * time.Second,
+		pending:  make(map[string]runtime.Object),
+	}
+
+	return q, nil
+}
+
+// Update sends the status of obj to the API. If the API can't be reached, a copy of obj is queued, replacing any older update
+// of the same object, and the error is returned.
+func (q *StatusQueue) Update(ctx context.Context, obj runtime.Object) error {
+	key := statusKey(obj)
+
+	// a newer update supersedes the queued one
+	q.mu.Lock()
+	if _, found := q.pending[key]; found {
+		delete(q.pending, key)
+		pendingStatusUpdates.Dec()
+	}
+	q.mu.Unlock()
+
+	err := q.client.UpdateStatus(ctx, obj)
+	if client.IsRetryable(err) {
+		q.mu.Lock()
+		if _, found := q.pending[key]; !found {
+			pendingStatusUpdates.Inc()
+		}
+		q.pending[key] = shallowCopy(obj)
+		q.mu.Unlock()
+	}
+
+	return err
+}
+
+// Replay sends the queued update of obj, if any, and stores the result in obj. It's meant to be called before
+// acting on an object retrieved from the API, which doesn't reflect the queued update yet. If the API is still
+// unavailable, obj is set to the queued update and the error is returned.
+func (q *StatusQueue) Replay(ctx context.Context, obj runtime.Object) error {
+	key := statusKey(obj)
+
+	q.mu.Lock()
+	queued, found := q.pending[key]
+	q.mu.Unlock()
+	if !found {
+		return nil
+	}
+
+	update := shallowCopy(queued)
+	err := q.client.UpdateStatus(ctx, update)
+	if client.IsRetryable(err) {
+		reflect.Indirect(reflect.ValueOf(obj)).Set(reflect.Indirect(reflect.ValueOf(queued)))
+		return err
+	}
+
+	q.forget(key, queued)
+	if err != nil {
+		// the API rejected the update, so there's no point in sending it again
+		log.Error(err, "dropping queued status update", "name", obj.GetName())
+		return nil
+	}
+
+	reflect.Indirect(reflect.ValueOf(obj)).Set(reflect.Indirect(reflect.ValueOf(update)))
+	return nil
+}
+
+// Start replays the queued updates until the context is done. It's designed to be run by a manager.
+func (q *StatusQueue) Start(ctx context.Context) error {
+	t := time.NewTicker(q.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			q.replayAll(ctx)
+		}
+	}
+}
+
+func (q *StatusQueue) replayAll(ctx context.Context) {
+	q.mu.Lock()
+	pending := make(map[string]runtime.Object, len(q.pending))
+	for key, obj := range q.pending {
+		pending[key] = obj
+	}
+	q.mu.Unlock()
+
+	for key, queued := range pending {
+		err := q.client.UpdateStatus(ctx, shallowCopy(queued))
+		if client.IsRetryable(err) {
+			log.V(1).Info("status update still pending", "name", queued.GetName(), "error", err.Error())
+			continue
+		}
+		if err != nil {
+			log.Error(err, "dropping queued status update", "name", queued.GetName())
+		} else {
+			log.Info("replayed status update", "name", queued.GetName())
+		}
+		q.forget(key, queued)
+	}
+}
+
+// forget removes a queued update, unless it was replaced in the meantime.
+func (q *StatusQueue) forget(key string, queued runtime.Object) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.pending[key] == queued {
+		delete(q.pending, key)
+		pendingStatusUpdates.Dec()
+	}
+}
+
+func statusKey(obj runtime.Object) string {
+	return runtime.RealTypeOf(obj).String() + "/" + obj.GetName()
+}
+
+// shallowCopy returns a copy of obj, so the queued update isn't affected by later changes of the caller.
+func shallowCopy(obj runtime.Object) runtime.Object {
+	value := reflect.Indirect(reflect.ValueOf(obj))
+	copied := reflect.New(value.Type())
+	copied.Elem().Set(value)
+
+	return copied.Interface().(runtime.Object)
+}
@@ -0,0 +1,53 @@
+package loadtesting
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/api"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/client"
+	"github.com/ReviewSignal/loadtesting/k6-operator/internal/loadtesting/fake"
+)
+
+var _ = Describe("StatusQueue", func() {
+	const location = "queue-test"
+
+	var (
+		ctx   context.Context
+		fapi  *fake.API
+		queue *StatusQueue
+		job   *api.Job
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fapi = fake.NewAPI()
+		Expect(fapi.AddRun(fake.Run{Name: "queued", Locations: []fake.Location{{Name: location, Workers: 1}}})).To(Succeed())
+
+		var err error
+		queue, err = NewStatusQueue(fapi.Client(location))
+		Expect(err).NotTo(HaveOccurred())
+
+		job = &api.Job{}
+		Expect(fapi.Client(location).Get(ctx, "queued", job)).To(Succeed())
+	})
+
+	It("never queues conflicting updates", func() {
+		Expect(fapi.SetStatus(location, "queued", api.STATUS_QUEUED, "Changed elsewhere")).To(Succeed())
+
+		// without a version, the fake API detects the conflict from the update time and answers 409
+		job.Version = ""
+		job.Status = api.STATUS_QUEUED
+		job.StatusDescription = "Stale"
+		err := queue.Update(ctx, job)
+		Expect(client.IsConflict(err)).To(BeTrue())
+		Expect(queue.pending).To(BeEmpty())
+
+		fresh := &api.Job{}
+		Expect(fapi.Client(location).Get(ctx, "queued", fresh)).To(Succeed())
+		Expect(queue.Replay(ctx, fresh)).To(Succeed())
+		Expect(fresh.StatusDescription).To(Equal("Changed elsewhere"))
+	})
+})
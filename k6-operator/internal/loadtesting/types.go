@@ -6,6 +6,9 @@ import (
 
 type Client = client.Client
 type StatusError = client.StatusError
+type ClockSource = client.ClockSource
 
 var IsNotFound = client.IsNotFound
 var IgnoreNotFound = client.IgnoreNotFound
+var IsRetryable = client.IsRetryable
+var IsConflict = client.IsConflict
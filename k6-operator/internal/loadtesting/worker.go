@@ -2,7 +2,9 @@ package loadtesting
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -13,6 +15,9 @@ import (
 
 var log = ctrl.Log.WithName("loadtesting-worker")
 
+// notifyTimeout is how long Notify waits for the controller to accept an event.
+const notifyTimeout = 5 * time.Second
+
 // Filter is a function that decides if an object should be sent as a reconcile event in k8s controllers.
 type Filter func(object runtime.Object) bool
 
@@ -67,6 +72,35 @@ func (w *Worker) Start(ctx context.Context) error {
 	}
 }
 
+// Notify retrieves an object and sends it to the controller right away, as if it was found by the watcher.
+func (w *Worker) Notify(ctx context.Context, name string) error {
+	obj, err := runtime.Schema.NewObj(w.resource)
+	if err != nil {
+		return err
+	}
+
+	if err := w.client.Get(ctx, name, obj); err != nil {
+		return err
+	}
+
+	if w.C == nil || !w.isValid(obj) {
+		return nil
+	}
+
+	// the controller only reads events while this replica is the leader
+	timeout := time.NewTimer(notifyTimeout)
+	defer timeout.Stop()
+
+	select {
+	case w.C <- event.GenericEvent{Object: obj.ToK8SResource()}:
+		return nil
+	case <-timeout.C:
+		return errors.New("controller is not running")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (w *Worker) isValid(obj runtime.Object) bool {
 	for _, filter := range w.filters {
 		if !filter(obj) {
@@ -1,7 +1,89 @@
 package options
 
-var Region string = ""
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
 var APIEndpoint string = "http://localhost:8000/api"
 var APIUser string = "admin"
 var APIPassword string = "admin"
 var JobNamespace string = ""
+
+var MaxClockSkew time.Duration = 2 * time.Second
+
+// Resilience settings of the API client.
+var APIRetries int = 3
+var APIQPS float64 = 10
+var APIBurst int = 20
+var APIBreakerThreshold int = 5
+var APIBreakerCooldown time.Duration = 30 * time.Second
+var APIWatchTransport string = "auto"
+
+// Garbage collection of the resources of test runs removed from the API. A zero OrphanSweepInterval disables it.
+var OrphanRetention time.Duration = 15 * time.Minute
+var OrphanSweepInterval time.Duration = 10 * time.Minute
+
+const (
+	// NamespaceModeShared creates all the jobs in JobNamespace.
+	NamespaceModeShared = "shared"
+	// NamespaceModePerTestRun creates a dedicated namespace for every test run.
+	NamespaceModePerTestRun = "per-test-run"
+	// NamespaceModePerTeam creates a namespace for every value of the NamespaceTeamLabel test run label.
+	NamespaceModePerTeam = "per-team"
+)
+
+const (
+	// SourceAPI reads the test runs from the webapp API.
+	SourceAPI = "api"
+	// SourceCRD reads the test runs from the TestRun resources in the namespace of the location.
+	SourceCRD = "crd"
+)
+
+// Source is where the test runs are read from, unless a location sets its own.
+var Source string = SourceAPI
+
+var NamespaceMode string = NamespaceModeShared
+var NamespacePrefix string = "orderly-ape"
+var NamespaceTeamLabel string = "team"
+
+// Location is the configuration of a single location served by the operator.
+type Location struct {
+	// Name is the name of the location in the Orderly Ape webapp.
+	Name string `json:"name"`
+	// Source is where the test runs of this location are read from: api or crd. Defaults to Source.
+	Source string `json:"source,omitempty"`
+	// APIEndpoint, APIUser and APIPassword default to the global ones.
+	APIEndpoint string `json:"apiEndpoint,omitempty"`
+	APIUser     string `json:"apiUser,omitempty"`
+	APIPassword string `json:"apiPassword,omitempty"`
+	// APIToken is a bearer token used instead of the global authentication methods.
+	APIToken string `json:"apiToken,omitempty"`
+	// Namespace where the jobs of this location are created. Defaults to JobNamespace.
+	Namespace    string              `json:"namespace,omitempty"`
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+	// Workers are the default resources of the worker pods, used when the test run doesn't specify them.
+	Workers corev1.ResourceList `json:"workers,omitempty"`
+	// Sizing is the capacity of the worker pods, used to size the test runs from the execution requirements of
+	// their script.
+	Sizing SizingProfile `json:"sizing,omitempty"`
+}
+
+// SizingProfile describes how many VUs the worker pods of a location can run.
+type SizingProfile struct {
+	// VUsPerCPU is the number of VUs a CPU core can run. Defaults to 200.
+	VUsPerCPU int64 `json:"vusPerCPU,omitempty"`
+	// MemoryPerVU is the memory used by each VU. Defaults to 4Mi.
+	MemoryPerVU *resource.Quantity `json:"memoryPerVU,omitempty"`
+	// BaseMemory is the memory used by k6 regardless of the number of VUs. Defaults to 256Mi.
+	BaseMemory *resource.Quantity `json:"baseMemory,omitempty"`
+	// MaxWorkers caps the worker count of a test run in the location. Zero means no limit.
+	MaxWorkers int32 `json:"maxWorkers,omitempty"`
+}
+
+// LocationNamespaces maps location names to the namespace where their jobs are created. It's set once at startup,
+// before the reconcilers start reading it, and never changes afterwards.
+var LocationNamespaces = map[string]string{}
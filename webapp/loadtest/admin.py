@@ -122,6 +122,11 @@ class TestRunLocationInline(admin.TabularInline):
         "online_workers",
         "status",
         "status_description",
+        "conditions",
+        "ignition",
+        "sizing",
+        "lost_segments",
+        "disrupted_segments",
     )
 
     @cached_property
@@ -214,6 +219,12 @@ class TestRunAdmin(admin.ModelAdmin):
                 "dedicated_nodes",
                 "node_selector",
                 "job_deadline",
+                "abort_if_incomplete",
+                "start_confirmation_window",
+                "late_start_policy",
+                "late_start_tolerance",
+                "sizing",
+                "failure_tolerance",
             ] + readonly_fields
         return readonly_fields
 
@@ -378,9 +389,22 @@ class TestRunAdmin(admin.ModelAdmin):
         return autocomplete_urls + super().get_urls()
 
 
+class TestLocationForm(ModelForm):
+    class Meta:  # pyright: ignore reportIncompatibleVariableOverride
+        model = TestLocation
+        fields = "__all__"
+        widgets = {
+            "notification_secret": forms.PasswordInput(
+                render_value=True, attrs={"autocomplete": "off", "class": "vTextField"}
+            ),
+        }
+
+
 @admin.register(TestLocation)
 class TestLocationAdmin(admin.ModelAdmin):
-    list_display = ["name", "display_name", "status", "last_ping"]
+    form = TestLocationForm
+    list_display = ["name", "display_name", "status", "last_ping", "clock_skew"]
+    readonly_fields = ["clock_skew"]
     prepopulated_fields = {"name": ["display_name"]}
 
     @admin.display(boolean=True)
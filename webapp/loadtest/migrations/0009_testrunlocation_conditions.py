@@ -0,0 +1,18 @@
+# Generated by Django 5.1.2 on 2026-10-19 12:00
+
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('loadtest', '0008_auto_20241202_1722'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='testrunlocation',
+            name='conditions',
+            field=models.JSONField(blank=True, default=list, help_text='State of each phase of the job, as reported by the operator.', verbose_name='Conditions'),
+        ),
+    ]
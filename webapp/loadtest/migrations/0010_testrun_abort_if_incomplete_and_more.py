@@ -0,0 +1,29 @@
+# Generated by Django 5.1.2 on 2026-10-19 12:00
+
+import loadtest.validators
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('loadtest', '0009_testrunlocation_conditions'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='testrun',
+            name='abort_if_incomplete',
+            field=models.BooleanField(default=False, help_text="If enabled, the test is stopped in all locations when any of them doesn't confirm it started within the start confirmation window.", verbose_name='Abort if incomplete'),
+        ),
+        migrations.AddField(
+            model_name='testrun',
+            name='start_confirmation_window',
+            field=models.CharField(default='30s', help_text='Time allowed, after the test start time, for all locations to confirm they started. Use Golang duration format (https://pkg.go.dev/time#Duration).', max_length=16, validators=[loadtest.validators.validate_duration], verbose_name='Start confirmation window'),
+        ),
+        migrations.AddField(
+            model_name='testrunlocation',
+            name='ignition',
+            field=models.JSONField(blank=True, help_text='Time each worker started the test, as confirmed by the operator.', null=True, verbose_name='Ignition'),
+        ),
+    ]
@@ -0,0 +1,24 @@
+# Generated by Django 5.1.2 on 2026-10-19 12:00
+
+import loadtest.validators
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('loadtest', '0010_testrun_abort_if_incomplete_and_more'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='testrunlocation',
+            name='ignition_stagger',
+            field=models.CharField(default='0s', help_text='Time to spread the start of the workers of this location over, instead of starting all of them at once. Use Golang duration format (https://pkg.go.dev/time#Duration).', max_length=16, validators=[loadtest.validators.validate_duration], verbose_name='Ignition stagger'),
+        ),
+        migrations.AddField(
+            model_name='testrunlocation',
+            name='start_offset',
+            field=models.CharField(default='0s', help_text='Delay of the start of this location, relative to the test start time. Use Golang duration format (https://pkg.go.dev/time#Duration).', max_length=16, validators=[loadtest.validators.validate_duration], verbose_name='Start offset'),
+        ),
+    ]
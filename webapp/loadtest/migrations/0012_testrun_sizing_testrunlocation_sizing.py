@@ -0,0 +1,23 @@
+# Generated by Django 5.1.2 on 2026-10-19 12:00
+
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('loadtest', '0011_testrunlocation_ignition_stagger_and_more'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='testrun',
+            name='sizing',
+            field=models.CharField(choices=[('off', 'Use the configured workers'), ('recommend', 'Recommend workers'), ('auto', 'Size workers automatically')], default='off', help_text='Inspect the test script before running it, to recommend or automatically set the number of workers and their memory in each location. When automatic, the per-worker CPU is kept and the workers of each location are replaced by the recommended ones.', max_length=16, verbose_name='Worker sizing'),
+        ),
+        migrations.AddField(
+            model_name='testrunlocation',
+            name='sizing',
+            field=models.JSONField(blank=True, help_text='Workers and resources recommended by the operator, from the execution requirements of the test script.', null=True, verbose_name='Sizing'),
+        ),
+    ]
@@ -0,0 +1,24 @@
+# Generated by Django 5.1.2 on 2026-10-19 12:00
+
+import loadtest.validators
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('loadtest', '0012_testrun_sizing_testrunlocation_sizing'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='testrun',
+            name='failure_tolerance',
+            field=models.CharField(default='0', help_text="Number (ex. 2) or percentage (ex. 10%) of the workers of each location which can fail while the test still completes, as degraded. Workers evicted by a node disruption are replaced and don't count as failures.", max_length=8, validators=[loadtest.validators.validate_failure_tolerance], verbose_name='Failure tolerance'),
+        ),
+        migrations.AddField(
+            model_name='testrunlocation',
+            name='lost_segments',
+            field=models.JSONField(blank=True, default=list, help_text='Segments no worker completed, as reported by the operator.', verbose_name='Lost segments'),
+        ),
+    ]
@@ -0,0 +1,18 @@
+# Generated by Django 5.1.2 on 2026-10-19 12:00
+
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('loadtest', '0013_testrun_failure_tolerance_and_more'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='testrunlocation',
+            name='disrupted_segments',
+            field=models.JSONField(blank=True, default=list, help_text='Lost segments whose worker was evicted by a node disruption (drain, preemption, ...) after the test started, instead of failing.', verbose_name='Disrupted segments'),
+        ),
+    ]
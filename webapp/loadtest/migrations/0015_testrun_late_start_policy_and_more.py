@@ -0,0 +1,24 @@
+# Generated by Django 5.1.2 on 2026-10-19 12:00
+
+import loadtest.validators
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('loadtest', '0014_testrunlocation_disrupted_segments'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='testrun',
+            name='late_start_policy',
+            field=models.CharField(choices=[('start', 'Start anyway'), ('fail', 'Fail the location')], default='start', help_text='What a location does when its workers are ready after the test start time, by more than the late start tolerance. Either way, the lateness is reported with the ignition of the location.', max_length=16, verbose_name='Late start policy'),
+        ),
+        migrations.AddField(
+            model_name='testrun',
+            name='late_start_tolerance',
+            field=models.CharField(default='5s', help_text='Lateness accepted before the late start policy applies. Use Golang duration format (https://pkg.go.dev/time#Duration).', max_length=16, validators=[loadtest.validators.validate_duration], verbose_name='Late start tolerance'),
+        ),
+    ]
@@ -0,0 +1,19 @@
+# Generated by Django 5.1.2 on 2026-10-19 12:00
+
+import loadtest.validators
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('loadtest', '0015_testrun_late_start_policy_and_more'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='testlocation',
+            name='clock_skew',
+            field=models.CharField(blank=True, help_text='Offset between the webapp clock and the operator clock, as measured by the operator on its last checkin.', max_length=32, validators=[loadtest.validators.validate_duration], verbose_name='Clock skew'),
+        ),
+    ]
@@ -0,0 +1,23 @@
+# Generated by Django 5.1.2 on 2026-10-19 12:00
+
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('loadtest', '0016_testlocation_clock_skew'),
+    ]
+
+    operations = [
+        migrations.AddField(
+            model_name='testlocation',
+            name='notification_url',
+            field=models.URLField(blank=True, help_text='Operator endpoint the job changes are sent to, ex. https://orderly-ape.example.com/notify. When empty, the operator only polls for them.', verbose_name='Notification URL'),
+        ),
+        migrations.AddField(
+            model_name='testlocation',
+            name='notification_secret',
+            field=models.CharField(blank=True, help_text='Shared secret signing the notifications, as set on the operator.', max_length=200, verbose_name='Notification secret'),
+        ),
+    ]
@@ -12,7 +12,7 @@ from django.utils.text import slugify
 from django.utils.translation import gettext_lazy as _
 from django_fsm import FSMField, transition
 
-from .validators import validate_duration
+from .validators import validate_duration, validate_failure_tolerance
 
 if TYPE_CHECKING:
     from django.db.models import Manager
@@ -54,6 +54,31 @@ class TestLocation(BaseNamedModel):
     last_ping = models.DateTimeField(
         verbose_name=_("Location last checkin"), editable=False, null=True
     )
+    clock_skew = models.CharField(
+        max_length=32,
+        blank=True,
+        validators=[validate_duration],
+        verbose_name=_("Clock skew"),
+        help_text=_(
+            "Offset between the webapp clock and the operator clock, as measured by "
+            "the operator on its last checkin."
+        ),
+    )
+    notification_url = models.URLField(
+        blank=True,
+        verbose_name=_("Notification URL"),
+        help_text=_(
+            "Operator endpoint the job changes are sent to, ex. "
+            "https://orderly-ape.example.com/notify. When empty, the operator only "
+            "polls for them."
+        ),
+    )
+    notification_secret = models.CharField(
+        max_length=200,
+        blank=True,
+        verbose_name=_("Notification secret"),
+        help_text=_("Shared secret signing the notifications, as set on the operator."),
+    )
 
     def ping(self):
         self.last_ping = timezone.now()
@@ -100,6 +125,15 @@ class TestOutputConfig(BaseNamedModel):
 
 
 class TestRun(BaseNamedModel):
+    class Sizing(models.TextChoices):
+        OFF = "off", _("Use the configured workers")
+        RECOMMEND = "recommend", _("Recommend workers")
+        AUTO = "auto", _("Size workers automatically")
+
+    class LateStartPolicy(models.TextChoices):
+        START = "start", _("Start anyway")
+        FAIL = "fail", _("Fail the location")
+
     locations: 'Manager["TestRunLocation"]'
     env_vars: 'Manager["TestRunEnvVar"]'
     labels: 'Manager["TestRunLabel"]'
@@ -194,6 +228,72 @@ class TestRun(BaseNamedModel):
         validators=[validate_duration],
     )
 
+    abort_if_incomplete = models.BooleanField(
+        default=False,
+        verbose_name=_("Abort if incomplete"),
+        help_text=_(
+            "If enabled, the test is stopped in all locations when any of them "
+            "doesn't confirm it started within the start confirmation window."
+        ),
+    )
+    start_confirmation_window = models.CharField(
+        default="30s",
+        max_length=16,
+        verbose_name=_("Start confirmation window"),
+        help_text=_(
+            "Time allowed, after the test start time, for all locations to confirm "
+            "they started. Use Golang duration format (https://pkg.go.dev/time#Duration)."
+        ),
+        validators=[validate_duration],
+    )
+
+    late_start_policy = models.CharField(
+        default=LateStartPolicy.START,
+        max_length=16,
+        choices=LateStartPolicy.choices,
+        verbose_name=_("Late start policy"),
+        help_text=_(
+            "What a location does when its workers are ready after the test start "
+            "time, by more than the late start tolerance. Either way, the lateness "
+            "is reported with the ignition of the location."
+        ),
+    )
+    late_start_tolerance = models.CharField(
+        default="5s",
+        max_length=16,
+        verbose_name=_("Late start tolerance"),
+        help_text=_(
+            "Lateness accepted before the late start policy applies. "
+            "Use Golang duration format (https://pkg.go.dev/time#Duration)."
+        ),
+        validators=[validate_duration],
+    )
+
+    sizing = models.CharField(
+        default=Sizing.OFF,
+        max_length=16,
+        choices=Sizing.choices,
+        verbose_name=_("Worker sizing"),
+        help_text=_(
+            "Inspect the test script before running it, to recommend or "
+            "automatically set the number of workers and their memory in each location. "
+            "When automatic, the per-worker CPU is kept and the workers of each location "
+            "are replaced by the recommended ones."
+        ),
+    )
+
+    failure_tolerance = models.CharField(
+        default="0",
+        max_length=8,
+        verbose_name=_("Failure tolerance"),
+        help_text=_(
+            "Number (ex. 2) or percentage (ex. 10%) of the workers of each location "
+            "which can fail while the test still completes, as degraded. Workers "
+            "evicted by a node disruption are replaced and don't count as failures."
+        ),
+        validators=[validate_failure_tolerance],
+    )
+
     draft = models.BooleanField(default=True, verbose_name=_("Draft"))
 
     @cached_property
@@ -211,6 +311,32 @@ class TestRun(BaseNamedModel):
     def ready(self) -> bool:
         return self.pk and self.locations.exclude(status="ready").count() == 0
 
+    @property
+    def confirmed(self) -> bool:
+        return self.pk and not self.locations.filter(ignition__isnull=True).exists()
+
+    @property
+    def sized(self) -> bool:
+        return self.pk and not self.locations.filter(sizing__isnull=True).exists()
+
+    @property
+    def degraded(self) -> bool:
+        return self.pk and self.locations.exclude(lost_segments=[]).exists()
+
+    @property
+    def last_start_offset(self) -> str:
+        """Time, after the test start time, the last location is done starting its workers."""
+        last = timezone.timedelta()
+        if not self.pk:
+            return durationpy.to_str(last)
+
+        for location in self.locations.all():
+            offset = durationpy.from_str(location.start_offset) + durationpy.from_str(
+                location.ignition_stagger
+            )
+            last = max(last, offset)
+        return durationpy.to_str(last)
+
     @property
     def segments(self) -> list[str]:
         if not self.pk:
@@ -363,8 +489,66 @@ class TestRunLocation(BaseBareModel):
 
     online_workers = models.PositiveSmallIntegerField(default=0)
 
+    start_offset = models.CharField(
+        default="0s",
+        max_length=16,
+        verbose_name=_("Start offset"),
+        help_text=_(
+            "Delay of the start of this location, relative to the test start time. "
+            "Use Golang duration format (https://pkg.go.dev/time#Duration)."
+        ),
+        validators=[validate_duration],
+    )
+    ignition_stagger = models.CharField(
+        default="0s",
+        max_length=16,
+        verbose_name=_("Ignition stagger"),
+        help_text=_(
+            "Time to spread the start of the workers of this location over, "
+            "instead of starting all of them at once. "
+            "Use Golang duration format (https://pkg.go.dev/time#Duration)."
+        ),
+        validators=[validate_duration],
+    )
+
     status = FSMField(default=Status.PENDING, choices=Status.choices)
     status_description = models.TextField(blank=True)
+    conditions = models.JSONField(
+        default=list,
+        blank=True,
+        verbose_name=_("Conditions"),
+        help_text=_("State of each phase of the job, as reported by the operator."),
+    )
+    ignition = models.JSONField(
+        null=True,
+        blank=True,
+        verbose_name=_("Ignition"),
+        help_text=_("Time each worker started the test, as confirmed by the operator."),
+    )
+    sizing = models.JSONField(
+        null=True,
+        blank=True,
+        verbose_name=_("Sizing"),
+        help_text=_(
+            "Workers and resources recommended by the operator, from the "
+            "execution requirements of the test script."
+        ),
+    )
+    lost_segments = models.JSONField(
+        default=list,
+        blank=True,
+        verbose_name=_("Lost segments"),
+        help_text=_("Segments no worker completed, as reported by the operator."),
+    )
+    disrupted_segments = models.JSONField(
+        default=list,
+        blank=True,
+        verbose_name=_("Disrupted segments"),
+        help_text=_(
+            "Lost segments whose worker was evicted by a node disruption "
+            "(drain, preemption, ...) after the test started, instead of failing."
+        ),
+    )
 
     @property
     def assigned_segments(self):
@@ -446,6 +630,9 @@ class TestRunLocation(BaseBareModel):
     def retry(self, message: str | None = None):
         if message:
             self.status_description = message
+        self.ignition = None
+        self.lost_segments = []
+        self.disrupted_segments = []
 
     def __str__(self):
         return f"{self.test_run} - {self.location}"
@@ -474,6 +661,10 @@ def duplicate_test_run(obj: TestRun):
         location.test_run = obj
         location.status = TestRunLocation.Status.PENDING
         location.status_description = ""
+        location.ignition = None
+        location.sizing = None
+        location.lost_segments = []
+        location.disrupted_segments = []
         location.save()
 
     for env_var in env_vars:
@@ -0,0 +1,62 @@
+import hashlib
+import hmac
+import json
+import logging
+import time
+import urllib.error
+import urllib.request
+
+from django.db import transaction
+
+from .models import TestLocation
+
+logger = logging.getLogger(__name__)
+
+# Headers checked by the notification handler of the operator.
+SIGNATURE_HEADER = "X-Orderly-Ape-Signature"
+TIMESTAMP_HEADER = "X-Orderly-Ape-Timestamp"
+
+# Notifications are only a shortcut, the operator still polls for the changes it
+# missed, so they must not hold the requests changing the jobs for long.
+NOTIFICATION_TIMEOUT_SECONDS = 2
+
+
+def sign(secret: str, timestamp: int, body: bytes) -> str:
+    """Returns the signature of a notification sent at timestamp, the hex encoded
+    HMAC-SHA256 of "<timestamp>.<body>" prefixed by "sha256="."""
+    mac = hmac.new(secret.encode(), f"{timestamp}.".encode() + body, hashlib.sha256)
+    return "sha256=" + mac.hexdigest()
+
+
+def send_notification(location: TestLocation, job: str):
+    """Tells the operator of a location that a job changed, so it reconciles it
+    right away."""
+    if not location.notification_url or not location.notification_secret:
+        return
+
+    body = json.dumps({"location": location.name, "job": job}).encode()
+    timestamp = int(time.time())
+    request = urllib.request.Request(
+        location.notification_url,
+        data=body,
+        method="POST",
+        headers={
+            "Content-Type": "application/json",
+            TIMESTAMP_HEADER: str(timestamp),
+            SIGNATURE_HEADER: sign(location.notification_secret, timestamp, body),
+        },
+    )
+
+    try:
+        with urllib.request.urlopen(request, timeout=NOTIFICATION_TIMEOUT_SECONDS):
+            pass
+    except (urllib.error.URLError, TimeoutError) as e:
+        logger.warning(
+            "Failed notifying %s of changes to job %s: %s", location.name, job, e
+        )
+
+
+def notify_job_change(location: TestLocation, job: str):
+    """Notifies the operator of a location once the current transaction is
+    committed, so it reads the changes."""
+    transaction.on_commit(lambda: send_notification(location, job))
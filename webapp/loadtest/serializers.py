@@ -1,12 +1,24 @@
+from django.db import transaction
 from rest_framework import serializers
 from rest_framework.reverse import reverse
 
-from .models import TestOutputConfig, TestRun, TestRunLocation
+from .models import (
+    TestLocation,
+    TestOutputConfig,
+    TestRun,
+    TestRunEnvVar,
+    TestRunLabel,
+    TestRunLocation,
+)
 
 
 class TestRunSerializer(serializers.ModelSerializer):
     completed = serializers.BooleanField(read_only=True)
     ready = serializers.BooleanField(read_only=True)
+    confirmed = serializers.BooleanField(read_only=True)
+    sized = serializers.BooleanField(read_only=True)
+    degraded = serializers.BooleanField(read_only=True)
+    last_start_offset = serializers.CharField(read_only=True)
     segments = serializers.ListField(read_only=True)
     env_vars = serializers.SerializerMethodField(method_name="get_env_vars")
     labels = serializers.SerializerMethodField(method_name="get_labels")
@@ -32,21 +44,8 @@ class TestOutputConfigSerializer(serializers.ModelSerializer):
         exclude = ["id", "name", "created_at", "updated_at"]
 
 
-class JobSerializer(serializers.HyperlinkedModelSerializer):
-    name = serializers.CharField(source="test_run.name", read_only=True)
-    url = serializers.SerializerMethodField()
-    test_run = TestRunSerializer(read_only=True)
-    output_config = TestOutputConfigSerializer(
-        read_only=True, source="test_run.test_output"
-    )
-    assigned_segments = serializers.ListField(read_only=True)
-
-    def get_url(self, obj):
-        return reverse(
-            "testrunlocation-detail",
-            kwargs={"location": obj.location, "test_run__name": obj.test_run.name},
-            request=self.context.get("request"),
-        )
+class JobStatusMixin:
+    """Status transitions of the jobs, shared by the serializers the workers update them with."""
 
     def validate_status(self, status):
         if self.instance and status == self.instance.status:
@@ -66,6 +65,15 @@ class JobSerializer(serializers.HyperlinkedModelSerializer):
     def update(self, instance: TestRunLocation, validated_data: dict):
         status = validated_data.get("status")
 
+        # automatic sizing replaces the workers, which recomputes the segments of all the locations
+        sizing = validated_data.get("sizing")
+        if (
+            instance.test_run.sizing == TestRun.Sizing.AUTO
+            and isinstance(sizing, dict)
+            and int(sizing.get("workers") or 0) > 0
+        ):
+            validated_data["num_workers"] = int(sizing["workers"])
+
         if status and instance.status != status:
             transitions = [
                 t
@@ -78,6 +86,138 @@ class JobSerializer(serializers.HyperlinkedModelSerializer):
 
         return super().update(instance, validated_data)
 
+
+class JobSerializer(JobStatusMixin, serializers.HyperlinkedModelSerializer):
+    name = serializers.CharField(source="test_run.name", read_only=True)
+    url = serializers.SerializerMethodField()
+    test_run = TestRunSerializer(read_only=True)
+    output_config = TestOutputConfigSerializer(
+        read_only=True, source="test_run.test_output"
+    )
+    assigned_segments = serializers.ListField(read_only=True)
+
+    def get_url(self, obj):
+        return reverse(
+            "testrunlocation-detail",
+            kwargs={"location": obj.location, "test_run__name": obj.test_run.name},
+            request=self.context.get("request"),
+        )
+
     class Meta:  # pyright: ignore [reportIncompatibleVariableOverride]
         model = TestRunLocation
         fields = "__all__"
+
+
+class JobStatusSerializer(JobStatusMixin, serializers.ModelSerializer):
+    """The status of a job, as reported by the workers on its status endpoint."""
+
+    class Meta:  # pyright: ignore [reportIncompatibleVariableOverride]
+        model = TestRunLocation
+        fields = [
+            "status",
+            "status_description",
+            "conditions",
+            "ignition",
+            "sizing",
+            "lost_segments",
+            "disrupted_segments",
+        ]
+
+
+class NameValueField(serializers.DictField):
+    """Represents name/value related objects, like env vars and labels, as a dict."""
+
+    child = serializers.CharField(allow_blank=True)
+
+    def to_representation(self, value):
+        return {name: value for (name, value) in value.values_list("name", "value")}
+
+
+class TestLocationSerializer(serializers.ModelSerializer):
+    online = serializers.SerializerMethodField()
+
+    def get_online(self, obj):
+        return bool(obj.staus())
+
+    class Meta:  # pyright: ignore [reportIncompatibleVariableOverride]
+        model = TestLocation
+        fields = ["name", "display_name", "last_ping", "clock_skew", "online"]
+
+
+class PingSerializer(serializers.ModelSerializer):
+    """The heartbeat of the operator of a location."""
+
+    class Meta:  # pyright: ignore [reportIncompatibleVariableOverride]
+        model = TestLocation
+        fields = ["clock_skew"]
+
+
+class TestRunLocationSerializer(serializers.ModelSerializer):
+    location = serializers.SlugRelatedField(
+        slug_field="name", queryset=TestLocation.objects.all()
+    )
+
+    class Meta:  # pyright: ignore [reportIncompatibleVariableOverride]
+        model = TestRunLocation
+        fields = [
+            "location",
+            "num_workers",
+            "start_offset",
+            "ignition_stagger",
+            "online_workers",
+            "status",
+            "status_description",
+            "conditions",
+            "ignition",
+            "sizing",
+            "lost_segments",
+            "disrupted_segments",
+        ]
+        read_only_fields = [
+            "online_workers",
+            "status",
+            "status_description",
+            "conditions",
+            "ignition",
+            "sizing",
+            "lost_segments",
+            "disrupted_segments",
+        ]
+
+
+class TestRunDetailSerializer(serializers.ModelSerializer):
+    """Test runs as managed by users, through the API."""
+
+    env_vars = NameValueField(required=False)
+    labels = NameValueField(required=False)
+    locations = TestRunLocationSerializer(many=True)
+    test_output = serializers.SlugRelatedField(
+        slug_field="name", queryset=TestOutputConfig.objects.all(), required=False
+    )
+    grafana_url = serializers.CharField(read_only=True)
+
+    def validate_locations(self, locations):
+        if not locations:
+            raise serializers.ValidationError("At least one location is required.")
+        return locations
+
+    @transaction.atomic
+    def create(self, validated_data: dict):
+        env_vars = validated_data.pop("env_vars", {})
+        labels = validated_data.pop("labels", {})
+        locations = validated_data.pop("locations")
+
+        test_run = TestRun.objects.create(**validated_data)
+        for name, value in env_vars.items():
+            TestRunEnvVar.objects.create(test_run=test_run, name=name, value=value)
+        for name, value in labels.items():
+            TestRunLabel.objects.create(test_run=test_run, name=name, value=value)
+        for location in locations:
+            TestRunLocation.objects.create(test_run=test_run, **location)
+
+        return test_run
+
+    class Meta:  # pyright: ignore [reportIncompatibleVariableOverride]
+        model = TestRun
+        exclude = ["id"]
+        read_only_fields = ["name", "draft", "started_at", "completed_at"]
@@ -2,7 +2,8 @@ from django.db.models.signals import post_save
 from django.dispatch import receiver
 from django.utils import timezone
 
-from .models import TestRunLocation
+from .models import TestRun, TestRunLocation
+from .notifications import notify_job_change
 
 # Delay before starting the test run after all locations are ready so that the
 # locations have time to pick-up the start time and begin testing at the same time.
@@ -49,9 +50,32 @@ def cancel_on_failure(sender, instance: TestRunLocation, created, **kwargs):
         return
 
     if instance.status == TestRunLocation.Status.FAILED:
-        test_run.locations.exclude(
+        locations = test_run.locations.exclude(
             status__in=[TestRunLocation.Status.FAILED, TestRunLocation.Status.COMPLETED]
-        ).update(
+        )
+        canceled = list(locations.select_related("location"))
+        locations.update(
             status=TestRunLocation.Status.CANCELED,
             status_description=f"Canceled automatically: Job was failing in {instance.location}.",
         )
+        for location in canceled:
+            notify_job_change(location.location, test_run.name)
+
+
+# The operators are notified of the changes they don't make themselves: starting,
+# scheduling and canceling test runs. They poll for the others.
+@receiver(post_save, sender=TestRun, dispatch_uid="notify_test_run_change")
+def notify_test_run_change(sender, instance: TestRun, created, **kwargs):
+    if instance.draft:
+        return
+
+    for location in instance.locations.select_related("location"):
+        notify_job_change(location.location, instance.name)
+
+
+@receiver(post_save, sender=TestRunLocation, dispatch_uid="notify_cancel")
+def notify_cancel(sender, instance: TestRunLocation, created, **kwargs):
+    if instance.test_run is None or instance.status != TestRunLocation.Status.CANCELED:
+        return
+
+    notify_job_change(instance.location, instance.test_run.name)
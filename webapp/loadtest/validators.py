@@ -13,3 +13,14 @@ def validate_duration(value):
             ),
             params={"value": value},
         )
+
+
+def validate_failure_tolerance(value):
+    number = value[:-1] if value.endswith("%") else value
+    if not number.isdigit() or (value.endswith("%") and int(number) > 100):
+        raise ValidationError(
+            _(
+                "Invalid failure tolerance %(value)s. Use a number of workers (ex. 2) or a percentage (ex. 10%%)."
+            ),
+            params={"value": value},
+        )
@@ -1,9 +1,21 @@
+import hashlib
+import json
+
+from django.core.serializers.json import DjangoJSONEncoder
+from django.db import transaction
+from django.shortcuts import get_object_or_404
 from rest_framework import mixins, status, viewsets
 from rest_framework.decorators import action
 from rest_framework.response import Response
 
-from .models import TestLocation, TestRunLocation
-from .serializers import JobSerializer
+from .models import TestLocation, TestRun, TestRunLocation
+from .serializers import (
+    JobSerializer,
+    JobStatusSerializer,
+    PingSerializer,
+    TestLocationSerializer,
+    TestRunDetailSerializer,
+)
 
 
 class PingViewSet(viewsets.ViewSet):
@@ -29,12 +41,26 @@ class PingViewSet(viewsets.ViewSet):
     def create(self, request, *args, **kwargs):
         instance = self.get_object()
         if instance:
+            # the operator leaves the skew out until it could measure it
+            serializer = PingSerializer(
+                instance, data={"clock_skew": request.data.get("clock_skew") or ""}
+            )
+            serializer.is_valid(raise_exception=True)
             instance.ping()
-            instance.save()
+            serializer.save()
             return Response(status=status.HTTP_204_NO_CONTENT)
         return Response(status=status.HTTP_404_NOT_FOUND)
 
 
+def job_etag(data) -> str:
+    """Returns the version of a job, as a hash of its representation.
+
+    The representation depends on the other locations of the test run too, so a
+    hash catches every change the workers have to know about."""
+    content = json.dumps(data, sort_keys=True, cls=DjangoJSONEncoder)
+    return '"%s"' % hashlib.sha256(content.encode()).hexdigest()[:32]
+
+
 class WorkersJobsViewSet(
     mixins.ListModelMixin,
     mixins.RetrieveModelMixin,
@@ -66,9 +92,87 @@ class WorkersJobsViewSet(
 
         return qs
 
+    def retrieve(self, request, *args, **kwargs):
+        data = self.get_serializer(self.get_object()).data
+        return Response(data, headers={"ETag": job_etag(data)})
+
+    def update(self, request, *args, **kwargs):
+        return self.update_job(request, JobSerializer, partial=kwargs.pop("partial", False))
+
+    @action(detail=True, methods=["patch"], url_path="status")
+    def update_status(self, request, *args, **kwargs):
+        return self.update_job(request, JobStatusSerializer, partial=True)
+
+    @transaction.atomic
+    def update_job(self, request, serializer_class, partial):
+        """Updates the job with serializer_class, if it wasn't changed since the
+        version sent in the If-Match header was retrieved."""
+        instance = get_object_or_404(
+            self.get_queryset().select_for_update(of=("self",)),
+            test_run__name=self.kwargs[self.lookup_field],
+        )
+        self.check_object_permissions(request, instance)
+
+        if_match = request.headers.get("If-Match")
+        if if_match and if_match != "*":
+            current = job_etag(self.get_serializer(instance).data)
+            if current not in [v.strip() for v in if_match.split(",")]:
+                return Response(
+                    {"detail": "The job was changed since it was retrieved."},
+                    status=status.HTTP_412_PRECONDITION_FAILED,
+                    headers={"ETag": current},
+                )
+
+        serializer = serializer_class(
+            instance,
+            data=request.data,
+            partial=partial,
+            context=self.get_serializer_context(),
+        )
+        serializer.is_valid(raise_exception=True)
+        serializer.save()
+
+        data = self.get_serializer(instance).data
+        return Response(data, headers={"ETag": job_etag(data)})
+
     @action(detail=True, methods=["post"])
     def accept(self, request, *args, **kwargs):
         instance = self.get_object()
         instance.enqueue()
         instance.save()
         return Response(status=status.HTTP_200_OK)
+
+
+class LocationsViewSet(mixins.ListModelMixin, viewsets.GenericViewSet):
+    serializer_class = TestLocationSerializer
+    queryset = TestLocation.objects.order_by("name")
+
+
+class TestRunsViewSet(
+    mixins.CreateModelMixin,
+    mixins.ListModelMixin,
+    mixins.RetrieveModelMixin,
+    viewsets.GenericViewSet,
+):
+    serializer_class = TestRunDetailSerializer
+    queryset = TestRun.objects.prefetch_related(
+        "locations", "env_vars", "labels"
+    ).order_by("-created_at")
+    lookup_field = "name"
+
+    @action(detail=True, methods=["post"])
+    def start(self, request, *args, **kwargs):
+        instance = self.get_object()
+        if not instance.draft:
+            return Response(
+                {"detail": "Test run was already started."},
+                status=status.HTTP_409_CONFLICT,
+            )
+        instance.start()
+        return Response(self.get_serializer(instance).data)
+
+    @action(detail=True, methods=["post"])
+    def cancel(self, request, *args, **kwargs):
+        instance = self.get_object()
+        instance.cancel(request.data.get("message") or "Canceled through the API.")
+        return Response(self.get_serializer(instance).data)
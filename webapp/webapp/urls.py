@@ -22,7 +22,12 @@ from django.http import HttpResponse
 from django.urls import include, path
 from rest_framework import routers
 
-from loadtest.views import PingViewSet, WorkersJobsViewSet
+from loadtest.views import (
+    LocationsViewSet,
+    PingViewSet,
+    TestRunsViewSet,
+    WorkersJobsViewSet,
+)
 
 
 def ok(_):
@@ -32,6 +37,8 @@ def ok(_):
 router = routers.DefaultRouter(trailing_slash=False)
 router.register("workers/(?P<location>[a-z0-9-]+)/jobs", WorkersJobsViewSet)
 router.register("workers/(?P<location>[a-z0-9-]+)/ping", PingViewSet, basename="ping")
+router.register("testruns", TestRunsViewSet)
+router.register("locations", LocationsViewSet)
 
 urlpatterns = [
     path("", ok, name="ok"),